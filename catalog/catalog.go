@@ -0,0 +1,257 @@
+// Package catalog bulk imports and exports movies, venues, screens and showtimes as CSV or JSON.
+// It is shared by the admin endpoints and cmd/catalog, so it takes the DB handle it works on.
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of records that can be imported and exported
+const (
+	KindMovies    = "movies"
+	KindVenues    = "venues"
+	KindScreens   = "screens"
+	KindShowTimes = "showtimes"
+)
+
+// Supported file formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// List values in a single CSV cell are separated by this
+const listSeparator = "|"
+
+var (
+	ErrUnknownKind   = errors.New("unknown catalog kind")
+	ErrUnknownFormat = errors.New("unknown catalog format, use csv or json")
+	// ErrRowsFailed means nothing was written because at least one row had errors
+	ErrRowsFailed = errors.New("import has row errors, nothing was saved")
+)
+
+// RowError describes one problem with one input row. Row is 1-based and does not count the CSV header.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the outcome of an import
+type Report struct {
+	Kind    string     `json:"kind"`
+	DryRun  bool       `json:"dry_run"`
+	Total   int        `json:"total"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
+
+// record is one importable row. upsert finds the row by its natural key and creates or updates it.
+type record interface {
+	validate() []RowError
+	upsert(tx *gorm.DB) (created bool, err error)
+}
+
+// Import reads records of the given kind and upserts them in a single transaction.
+// If any row fails, or dryRun is set, the transaction is rolled back and the report says what would have happened.
+func Import(db *gorm.DB, kind, format string, r io.Reader, dryRun bool) (*Report, error) {
+	switch kind {
+	case KindMovies:
+		return importRecords(db, kind, format, r, dryRun, movieFromCSV)
+	case KindVenues:
+		return importRecords(db, kind, format, r, dryRun, venueFromCSV)
+	case KindScreens:
+		return importRecords(db, kind, format, r, dryRun, screenFromCSV)
+	case KindShowTimes:
+		return importRecords(db, kind, format, r, dryRun, showTimeFromCSV)
+	}
+	return nil, ErrUnknownKind
+}
+
+// Export writes every record of the given kind in a format Import accepts
+func Export(db *gorm.DB, kind, format string, w io.Writer) error {
+	if format != FormatCSV && format != FormatJSON {
+		return ErrUnknownFormat
+	}
+	switch kind {
+	case KindMovies:
+		return exportMovies(db, format, w)
+	case KindVenues:
+		return exportVenues(db, format, w)
+	case KindScreens:
+		return exportScreens(db, format, w)
+	case KindShowTimes:
+		return exportShowTimes(db, format, w)
+	}
+	return ErrUnknownKind
+}
+
+// FormatFromFilename picks csv or json from a file extension
+func FormatFromFilename(name string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(name), ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(strings.ToLower(name), ".json"):
+		return FormatJSON
+	}
+	return ""
+}
+
+func importRecords[T record](db *gorm.DB, kind, format string, r io.Reader, dryRun bool, fromCSV func(map[string]string) T) (*Report, error) {
+	records, err := decode(format, r, fromCSV)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Kind: kind, DryRun: dryRun, Total: len(records), Errors: []RowError{}}
+
+	// Validate everything up front so the report covers every bad row, not just the first
+	valid := make([]bool, len(records))
+	for i, rec := range records {
+		rowErrors := rec.validate()
+		for j := range rowErrors {
+			rowErrors[j].Row = i + 1
+		}
+		report.Errors = append(report.Errors, rowErrors...)
+		valid[i] = len(rowErrors) == 0
+		if !valid[i] {
+			report.Failed++
+		}
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	for i, rec := range records {
+		if !valid[i] {
+			continue
+		}
+		// A savepoint per row keeps one failed insert from aborting the whole transaction
+		savepoint := fmt.Sprintf("catalog_row_%d", i+1)
+		tx.SavePoint(savepoint)
+		created, err := rec.upsert(tx)
+		if err != nil {
+			tx.RollbackTo(savepoint)
+			report.Failed++
+			report.Errors = append(report.Errors, RowError{Row: i + 1, Message: err.Error()})
+			continue
+		}
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	if dryRun || report.Failed > 0 {
+		tx.Rollback()
+		if !dryRun {
+			return report, ErrRowsFailed
+		}
+		return report, nil
+	}
+	if err := tx.Commit().Error; err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func decode[T record](format string, r io.Reader, fromCSV func(map[string]string) T) ([]T, error) {
+	switch format {
+	case FormatJSON:
+		var records []T
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid JSON, expected an array of records: %w", err)
+		}
+		return records, nil
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil, nil
+		}
+		header := rows[0]
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+		records := make([]T, 0, len(rows)-1)
+		for _, row := range rows[1:] {
+			values := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(row) {
+					values[column] = strings.TrimSpace(row[i])
+				}
+			}
+			records = append(records, fromCSV(values))
+		}
+		return records, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func joinList(list []string) string {
+	return strings.Join(list, listSeparator)
+}
+
+// parseDate accepts a plain date or an RFC3339 timestamp, empty means no date
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD or RFC3339, got %q", value)
+	}
+	return &t, nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCSV_Movies(t *testing.T) {
+	input := "title,desc,duration,genres,release_date\n" +
+		"Inception,Dreams within dreams,2h 28m,Sci-Fi|Thriller,2010-07-16\n"
+	records, err := decode(FormatCSV, strings.NewReader(input), movieFromCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	m := records[0]
+	if m.Title != "Inception" || m.Duration != "2h 28m" {
+		t.Errorf("unexpected record: %+v", m)
+	}
+	if len(m.Genres) != 2 || m.Genres[1] != "Thriller" {
+		t.Errorf("expected genres split on |, got %v", m.Genres)
+	}
+	if errs := m.validate(); len(errs) != 0 {
+		t.Errorf("expected valid record, got %v", errs)
+	}
+}
+
+func TestDecodeJSON_ShowTimes(t *testing.T) {
+	input := `[{"movie_title": "Dune", "venue_name": "INOX", "venue_location": "Delhi", "timing": "12:00"}]`
+	records, err := decode(FormatJSON, strings.NewReader(input), showTimeFromCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].VenueName != "INOX" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestValidate_ReportsEveryField(t *testing.T) {
+	errs := MovieRecord{Title: "A", ReleaseDate: "next week"}.validate()
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"title", "desc", "duration", "release_date"} {
+		if !fields[f] {
+			t.Errorf("expected an error for %s, got %v", f, errs)
+		}
+	}
+}
+
+func TestDecode_UnknownFormat(t *testing.T) {
+	_, err := decode("xml", strings.NewReader(""), venueFromCSV)
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestDecodeCSV_Screens(t *testing.T) {
+	input := "venue_name,venue_location,name,capacity,formats,accessibility\n" +
+		"PVR,Mumbai,Audi 1,180,IMAX|3d,wheelchair\n" +
+		"PVR,Mumbai,Audi 2,lots,8K,\n"
+	records, err := decode(FormatCSV, strings.NewReader(input), screenFromCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if s := records[0]; s.Capacity != 180 || len(s.Formats) != 2 || len(s.Accessibility) != 1 {
+		t.Errorf("unexpected record: %+v", s)
+	}
+	if errs := records[0].validate(); len(errs) != 0 {
+		t.Errorf("expected valid record, got %v", errs)
+	}
+	fields := map[string]bool{}
+	for _, e := range records[1].validate() {
+		fields[e.Field] = true
+	}
+	if !fields["capacity"] || !fields["formats"] {
+		t.Errorf("expected capacity and formats errors, got %v", fields)
+	}
+}
+
+func TestDecodeCSV_VenueCoordinates(t *testing.T) {
+	input := "name,location,city,latitude,longitude\n" +
+		"PVR,Mumbai,Mumbai,18.9947,72.8258\n" +
+		"INOX,Delhi,,north,\n"
+	records, err := decode(FormatCSV, strings.NewReader(input), venueFromCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := records[0]; v.Latitude == nil || *v.Latitude != 18.9947 || v.City != "Mumbai" {
+		t.Errorf("unexpected record: %+v", v)
+	}
+	if errs := records[0].validate(); len(errs) != 0 {
+		t.Errorf("expected valid record, got %v", errs)
+	}
+	if errs := records[1].validate(); len(errs) == 0 || errs[0].Field != "latitude" {
+		t.Errorf("expected a latitude error, got %v", errs)
+	}
+}
@@ -0,0 +1,473 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+)
+
+// field is a named column value, kept in order so row errors come out in column order
+type field struct {
+	name  string
+	value string
+}
+
+// MovieRecord is keyed by title, case-insensitive
+type MovieRecord struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"desc"`
+	Duration     string   `json:"duration"`
+	Poster       string   `json:"poster"`
+	Genres       []string `json:"genres"`
+	Languages    []string `json:"languages"`
+	Cast         []string `json:"cast"`
+	PreBookingAt string   `json:"pre_booking_at"`
+	ReleaseDate  string   `json:"release_date"`
+	EndDate      string   `json:"end_date"`
+}
+
+var movieColumns = []string{"title", "desc", "duration", "poster", "genres", "languages", "cast", "pre_booking_at", "release_date", "end_date"}
+
+func movieFromCSV(row map[string]string) MovieRecord {
+	return MovieRecord{
+		Title:        row["title"],
+		Description:  row["desc"],
+		Duration:     row["duration"],
+		Poster:       row["poster"],
+		Genres:       splitList(row["genres"]),
+		Languages:    splitList(row["languages"]),
+		Cast:         splitList(row["cast"]),
+		PreBookingAt: row["pre_booking_at"],
+		ReleaseDate:  row["release_date"],
+		EndDate:      row["end_date"],
+	}
+}
+
+func (m MovieRecord) validate() []RowError {
+	var errs []RowError
+	if len(m.Title) < 2 || len(m.Title) > 50 {
+		errs = append(errs, RowError{Field: "title", Message: "must be 2 to 50 characters"})
+	}
+	if m.Description == "" {
+		errs = append(errs, RowError{Field: "desc", Message: "is required"})
+	}
+	if m.Duration == "" {
+		errs = append(errs, RowError{Field: "duration", Message: "is required"})
+	}
+	for _, f := range []field{{"pre_booking_at", m.PreBookingAt}, {"release_date", m.ReleaseDate}, {"end_date", m.EndDate}} {
+		if _, err := parseDate(f.value); err != nil {
+			errs = append(errs, RowError{Field: f.name, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+func (m MovieRecord) upsert(tx *gorm.DB) (bool, error) {
+	var movie models.Movie
+	err := tx.Where("LOWER(title) = LOWER(?)", m.Title).First(&movie).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	created := movie.ID == 0
+
+	movie.Title = m.Title
+	movie.Description = m.Description
+	movie.Duration = m.Duration
+	// Optional columns only overwrite when the file has a value for them
+	if m.Poster != "" && m.Poster != movie.Poster {
+		movie.Poster = m.Poster
+		movie.PosterVariants = nil
+	}
+	if len(m.Genres) > 0 {
+		movie.Genres = m.Genres
+	}
+	if len(m.Languages) > 0 {
+		movie.Languages = m.Languages
+	}
+	if len(m.Cast) > 0 {
+		movie.Cast = m.Cast
+	}
+	if t, _ := parseDate(m.PreBookingAt); t != nil {
+		movie.PreBookingAt = t
+	}
+	if t, _ := parseDate(m.ReleaseDate); t != nil {
+		movie.ReleaseDate = t
+	}
+	if t, _ := parseDate(m.EndDate); t != nil {
+		movie.EndDate = t
+	}
+	return created, tx.Save(&movie).Error
+}
+
+// VenueRecord is keyed by name and location. The address fields are optional; a city must already exist.
+type VenueRecord struct {
+	Name         string   `json:"name"`
+	Location     string   `json:"location"`
+	AddressLine1 string   `json:"address_line1,omitempty"`
+	AddressLine2 string   `json:"address_line2,omitempty"`
+	Locality     string   `json:"locality,omitempty"`
+	PostalCode   string   `json:"postal_code,omitempty"`
+	City         string   `json:"city,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+
+	// Set when a CSV coordinate isn't a number, so validate can report it
+	badCoordinate string
+}
+
+var venueColumns = []string{"name", "location", "address_line1", "address_line2", "locality", "postal_code", "city", "latitude", "longitude"}
+
+func venueFromCSV(row map[string]string) VenueRecord {
+	v := VenueRecord{
+		Name:         row["name"],
+		Location:     row["location"],
+		AddressLine1: row["address_line1"],
+		AddressLine2: row["address_line2"],
+		Locality:     row["locality"],
+		PostalCode:   row["postal_code"],
+		City:         row["city"],
+	}
+	for _, c := range []struct {
+		column string
+		value  **float64
+	}{{"latitude", &v.Latitude}, {"longitude", &v.Longitude}} {
+		if row[c.column] == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(row[c.column], 64)
+		if err != nil {
+			v.badCoordinate = c.column
+			continue
+		}
+		*c.value = &f
+	}
+	return v
+}
+
+func (v VenueRecord) validate() []RowError {
+	var errs []RowError
+	if v.Name == "" {
+		errs = append(errs, RowError{Field: "name", Message: "is required"})
+	}
+	if v.Location == "" {
+		errs = append(errs, RowError{Field: "location", Message: "is required"})
+	}
+	if v.badCoordinate != "" {
+		errs = append(errs, RowError{Field: v.badCoordinate, Message: "must be a number"})
+	}
+	if (v.Latitude == nil) != (v.Longitude == nil) {
+		errs = append(errs, RowError{Field: "latitude", Message: "latitude and longitude go together"})
+	}
+	if v.Latitude != nil && (*v.Latitude < -90 || *v.Latitude > 90) {
+		errs = append(errs, RowError{Field: "latitude", Message: "must be between -90 and 90"})
+	}
+	if v.Longitude != nil && (*v.Longitude < -180 || *v.Longitude > 180) {
+		errs = append(errs, RowError{Field: "longitude", Message: "must be between -180 and 180"})
+	}
+	return errs
+}
+
+func (v VenueRecord) upsert(tx *gorm.DB) (bool, error) {
+	var venue models.Venue
+	err := tx.Where("LOWER(name) = LOWER(?) AND LOWER(location) = LOWER(?)", v.Name, v.Location).First(&venue).Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return false, err
+	}
+	if v.City != "" {
+		var city models.City
+		if err := tx.Where("LOWER(name) = LOWER(?)", v.City).First(&city).Error; err != nil {
+			return false, fmt.Errorf("city %q not found", v.City)
+		}
+		venue.CityID = &city.ID
+	}
+	venue.Name = v.Name
+	venue.Location = v.Location
+	venue.AddressLine1 = v.AddressLine1
+	venue.AddressLine2 = v.AddressLine2
+	venue.Locality = v.Locality
+	venue.PostalCode = v.PostalCode
+	venue.Latitude = v.Latitude
+	venue.Longitude = v.Longitude
+	return created, tx.Save(&venue).Error
+}
+
+func formatCoordinate(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// ScreenRecord is keyed by venue and screen name. The venue must already exist.
+type ScreenRecord struct {
+	VenueName     string   `json:"venue_name"`
+	VenueLocation string   `json:"venue_location"`
+	Name          string   `json:"name"`
+	Capacity      int      `json:"capacity"`
+	Formats       []string `json:"formats"`
+	Accessibility []string `json:"accessibility"`
+}
+
+var screenColumns = []string{"venue_name", "venue_location", "name", "capacity", "formats", "accessibility"}
+
+func screenFromCSV(row map[string]string) ScreenRecord {
+	// A bad number is left at 0 so validate reports it
+	capacity, _ := strconv.Atoi(row["capacity"])
+	return ScreenRecord{
+		VenueName:     row["venue_name"],
+		VenueLocation: row["venue_location"],
+		Name:          row["name"],
+		Capacity:      capacity,
+		Formats:       splitList(row["formats"]),
+		Accessibility: splitList(row["accessibility"]),
+	}
+}
+
+func (s ScreenRecord) validate() []RowError {
+	var errs []RowError
+	for _, f := range []field{{"venue_name", s.VenueName}, {"venue_location", s.VenueLocation}, {"name", s.Name}} {
+		if f.value == "" {
+			errs = append(errs, RowError{Field: f.name, Message: "is required"})
+		}
+	}
+	if s.Capacity < 1 || s.Capacity > 1000 {
+		errs = append(errs, RowError{Field: "capacity", Message: "must be between 1 and 1000"})
+	}
+	for _, f := range s.Formats {
+		if _, ok := models.CanonicalFormat(f); !ok {
+			errs = append(errs, RowError{Field: "formats", Message: fmt.Sprintf("unknown format %q", f)})
+		}
+	}
+	for _, a := range s.Accessibility {
+		if !slices.Contains(models.AccessibilityFeatures, a) {
+			errs = append(errs, RowError{Field: "accessibility", Message: fmt.Sprintf("unknown feature %q", a)})
+		}
+	}
+	return errs
+}
+
+func (s ScreenRecord) upsert(tx *gorm.DB) (bool, error) {
+	var venue models.Venue
+	if err := tx.Where("LOWER(name) = LOWER(?) AND LOWER(location) = LOWER(?)", s.VenueName, s.VenueLocation).First(&venue).Error; err != nil {
+		return false, fmt.Errorf("venue %q in %q not found", s.VenueName, s.VenueLocation)
+	}
+	formats := []string{}
+	for _, f := range s.Formats {
+		format, _ := models.CanonicalFormat(f)
+		formats = append(formats, format)
+	}
+	var screen models.Screen
+	err := tx.Where("venue_id = ? AND LOWER(name) = LOWER(?)", venue.ID, s.Name).First(&screen).Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return false, err
+	}
+	screen.VenueID = venue.ID
+	screen.Name = s.Name
+	screen.Capacity = s.Capacity
+	screen.Formats = formats
+	screen.Accessibility = s.Accessibility
+	return created, tx.Save(&screen).Error
+}
+
+// ShowTimeRecord is keyed by movie, venue, screen and timing. The movie, venue and screen must already exist.
+// Screen and format are optional, for venues without screens and 2D shows.
+type ShowTimeRecord struct {
+	MovieTitle    string `json:"movie_title"`
+	VenueName     string `json:"venue_name"`
+	VenueLocation string `json:"venue_location"`
+	Timing        string `json:"timing"`
+	Screen        string `json:"screen,omitempty"`
+	Format        string `json:"format,omitempty"`
+}
+
+var showTimeColumns = []string{"movie_title", "venue_name", "venue_location", "timing", "screen", "format"}
+
+func showTimeFromCSV(row map[string]string) ShowTimeRecord {
+	return ShowTimeRecord{
+		MovieTitle:    row["movie_title"],
+		VenueName:     row["venue_name"],
+		VenueLocation: row["venue_location"],
+		Timing:        row["timing"],
+		Screen:        row["screen"],
+		Format:        row["format"],
+	}
+}
+
+func (s ShowTimeRecord) validate() []RowError {
+	var errs []RowError
+	for _, f := range []field{{"movie_title", s.MovieTitle}, {"venue_name", s.VenueName}, {"venue_location", s.VenueLocation}, {"timing", s.Timing}} {
+		if f.value == "" {
+			errs = append(errs, RowError{Field: f.name, Message: "is required"})
+		}
+	}
+	if _, ok := models.CanonicalFormat(s.Format); !ok {
+		errs = append(errs, RowError{Field: "format", Message: fmt.Sprintf("unknown format %q", s.Format)})
+	}
+	return errs
+}
+
+func (s ShowTimeRecord) upsert(tx *gorm.DB) (bool, error) {
+	var movie models.Movie
+	if err := tx.Where("LOWER(title) = LOWER(?)", s.MovieTitle).First(&movie).Error; err != nil {
+		return false, fmt.Errorf("movie %q not found", s.MovieTitle)
+	}
+	var venue models.Venue
+	if err := tx.Preload("City.Region").Preload("Operator").Where("LOWER(name) = LOWER(?) AND LOWER(location) = LOWER(?)", s.VenueName, s.VenueLocation).First(&venue).Error; err != nil {
+		return false, fmt.Errorf("venue %q in %q not found", s.VenueName, s.VenueLocation)
+	}
+	format, _ := models.CanonicalFormat(s.Format)
+	showTime := models.ShowTime{Timing: s.Timing, MovieID: movie.ID, VenueID: venue.ID, Format: format}
+	existing := tx.Model(&models.ShowTime{}).Where("movie_id = ? AND venue_id = ? AND timing = ?", movie.ID, venue.ID, s.Timing)
+	capacity := 0
+	if s.Screen != "" {
+		var screen models.Screen
+		if err := tx.Where("venue_id = ? AND LOWER(name) = LOWER(?)", venue.ID, s.Screen).First(&screen).Error; err != nil {
+			return false, fmt.Errorf("screen %q not found at %q", s.Screen, s.VenueName)
+		}
+		if !screen.SupportsFormat(format) {
+			return false, fmt.Errorf("screen %q does not support %s", screen.Name, format)
+		}
+		showTime.ScreenID = &screen.ID
+		capacity = screen.Capacity
+		existing = existing.Where("screen_id = ?", screen.ID)
+	}
+	var count int64
+	existing.Count(&count)
+	if count > 0 {
+		return false, nil
+	}
+	// Shows already there are kept even if the venue's hours have since changed
+	if err := venue.CheckShowTime(s.Timing, nil); err != nil {
+		return false, err
+	}
+	if err := tx.Model(&venue).Association("Movies").Append(&movie); err != nil {
+		return false, err
+	}
+	if err := tx.Create(&showTime).Error; err != nil {
+		return false, err
+	}
+	seats := helpers.GenerateSeatsForVenue(showTime.ID, capacity, venue)
+	return true, tx.Create(&seats).Error
+}
+
+func exportMovies(db *gorm.DB, format string, w io.Writer) error {
+	var movies []models.Movie
+	if err := db.Order("title").Find(&movies).Error; err != nil {
+		return err
+	}
+	records := make([]MovieRecord, len(movies))
+	rows := make([][]string, len(movies))
+	for i, m := range movies {
+		records[i] = MovieRecord{
+			Title:        m.Title,
+			Description:  m.Description,
+			Duration:     m.Duration,
+			Poster:       m.Poster,
+			Genres:       m.Genres,
+			Languages:    m.Languages,
+			Cast:         m.Cast,
+			PreBookingAt: formatDate(m.PreBookingAt),
+			ReleaseDate:  formatDate(m.ReleaseDate),
+			EndDate:      formatDate(m.EndDate),
+		}
+		r := records[i]
+		rows[i] = []string{r.Title, r.Description, r.Duration, r.Poster, joinList(r.Genres), joinList(r.Languages), joinList(r.Cast), r.PreBookingAt, r.ReleaseDate, r.EndDate}
+	}
+	if format == FormatJSON {
+		return writeJSON(w, records)
+	}
+	return writeCSV(w, movieColumns, rows)
+}
+
+func exportVenues(db *gorm.DB, format string, w io.Writer) error {
+	var venues []models.Venue
+	if err := db.Preload("City").Order("name, location").Find(&venues).Error; err != nil {
+		return err
+	}
+	records := make([]VenueRecord, len(venues))
+	rows := make([][]string, len(venues))
+	for i, v := range venues {
+		r := VenueRecord{
+			Name:         v.Name,
+			Location:     v.Location,
+			AddressLine1: v.AddressLine1,
+			AddressLine2: v.AddressLine2,
+			Locality:     v.Locality,
+			PostalCode:   v.PostalCode,
+			Latitude:     v.Latitude,
+			Longitude:    v.Longitude,
+		}
+		if v.City != nil {
+			r.City = v.City.Name
+		}
+		records[i] = r
+		rows[i] = []string{r.Name, r.Location, r.AddressLine1, r.AddressLine2, r.Locality, r.PostalCode, r.City, formatCoordinate(r.Latitude), formatCoordinate(r.Longitude)}
+	}
+	if format == FormatJSON {
+		return writeJSON(w, records)
+	}
+	return writeCSV(w, venueColumns, rows)
+}
+
+func exportScreens(db *gorm.DB, format string, w io.Writer) error {
+	var screens []models.Screen
+	if err := db.Order("venue_id, name").Find(&screens).Error; err != nil {
+		return err
+	}
+	venues := map[uint]models.Venue{}
+	var all []models.Venue
+	if err := db.Find(&all).Error; err != nil {
+		return err
+	}
+	for _, v := range all {
+		venues[v.ID] = v
+	}
+	records := make([]ScreenRecord, 0, len(screens))
+	rows := make([][]string, 0, len(screens))
+	for _, sc := range screens {
+		venue, ok := venues[sc.VenueID]
+		if !ok {
+			continue
+		}
+		r := ScreenRecord{VenueName: venue.Name, VenueLocation: venue.Location, Name: sc.Name, Capacity: sc.Capacity, Formats: sc.Formats, Accessibility: sc.Accessibility}
+		records = append(records, r)
+		rows = append(rows, []string{r.VenueName, r.VenueLocation, r.Name, strconv.Itoa(r.Capacity), joinList(r.Formats), joinList(r.Accessibility)})
+	}
+	if format == FormatJSON {
+		return writeJSON(w, records)
+	}
+	return writeCSV(w, screenColumns, rows)
+}
+
+func exportShowTimes(db *gorm.DB, format string, w io.Writer) error {
+	var showTimes []models.ShowTime
+	if err := db.Preload("Movie").Preload("Venue").Preload("Screen").Order("venue_id, movie_id, timing").Find(&showTimes).Error; err != nil {
+		return err
+	}
+	records := make([]ShowTimeRecord, 0, len(showTimes))
+	rows := make([][]string, 0, len(showTimes))
+	for _, st := range showTimes {
+		// Skip showtimes whose movie or venue has since been deleted
+		if st.Movie.ID == 0 || st.Venue.ID == 0 {
+			continue
+		}
+		r := ShowTimeRecord{MovieTitle: st.Movie.Title, VenueName: st.Venue.Name, VenueLocation: st.Venue.Location, Timing: st.Timing, Format: st.Format}
+		if st.Screen != nil {
+			r.Screen = st.Screen.Name
+		}
+		records = append(records, r)
+		rows = append(rows, []string{r.MovieTitle, r.VenueName, r.VenueLocation, r.Timing, r.Screen, r.Format})
+	}
+	if format == FormatJSON {
+		return writeJSON(w, records)
+	}
+	return writeCSV(w, showTimeColumns, rows)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Snehil208001/BookMyShowApp/catalog"
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage:
+  go run ./cmd/catalog import -kind movies|venues|screens|showtimes -file data.csv [-format csv|json] [-dry-run]
+  go run ./cmd/catalog export -kind movies|venues|screens|showtimes [-format csv|json] [-out file]`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	kind := flags.String("kind", "", "movies, venues, screens or showtimes")
+	file := flags.String("file", "", "file to import")
+	format := flags.String("format", "", "csv or json (default: from file extension, json for export)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without saving")
+	out := flags.String("out", "", "file to export to (default: stdout)")
+	flags.Parse(os.Args[2:])
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	dsn := os.Getenv("DB_URL")
+	if dsn == "" {
+		log.Fatal("DB_URL not set in .env")
+	}
+	db, err := gorm.Open(postgres.Open(dsn))
+	if err != nil {
+		log.Fatal("Error connecting to DB:", err)
+	}
+
+	switch command {
+	case "import":
+		if *file == "" {
+			log.Fatal(usage)
+		}
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal("Error opening file:", err)
+		}
+		defer f.Close()
+		if *format == "" {
+			*format = catalog.FormatFromFilename(*file)
+		}
+		report, err := catalog.Import(db, *kind, *format, f, *dryRun)
+		if report != nil {
+			encoded, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(encoded))
+		}
+		if err != nil {
+			log.Fatal("Import failed: ", err)
+		}
+		if *dryRun {
+			log.Println("Dry run only, nothing was saved")
+		}
+	case "export":
+		if *format == "" {
+			*format = catalog.FormatJSON
+		}
+		w := os.Stdout
+		if *out != "" {
+			if w, err = os.Create(*out); err != nil {
+				log.Fatal("Error creating file:", err)
+			}
+			defer w.Close()
+		}
+		if err := catalog.Export(db, *kind, *format, w); err != nil {
+			log.Fatal("Export failed: ", err)
+		}
+	default:
+		log.Fatal(usage)
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/Snehil208001/BookMyShowApp/models"
@@ -11,10 +15,36 @@ import (
 	"gorm.io/gorm"
 )
 
+// Creates a super admin, or makes an existing user one.
+//
+//	go run ./cmd/create-admin -email admin@example.com [-name Admin] [-password ...]
+//
+// The password can also come from ADMIN_PASSWORD. Without either a random one is generated and
+// printed once. Admins should turn on two-factor authentication after their first login.
 func main() {
+	email := flag.String("email", os.Getenv("ADMIN_EMAIL"), "email of the admin (or ADMIN_EMAIL)")
+	name := flag.String("name", "Admin", "name for a new admin")
+	password := flag.String("password", "", "password (or ADMIN_PASSWORD, default random)")
+	flag.Parse()
+
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
+	if *email == "" {
+		log.Fatal("Usage: go run ./cmd/create-admin -email <email> [-name <name>] [-password <password>]")
+	}
+	if *password == "" {
+		*password = os.Getenv("ADMIN_PASSWORD")
+	}
+	generated := *password == ""
+	if generated {
+		b := make([]byte, 12)
+		rand.Read(b)
+		*password = base64.RawURLEncoding.EncodeToString(b)
+	}
+	if len(*password) < 8 {
+		log.Fatal("Password must be at least 8 characters")
+	}
 
 	dsn := os.Getenv("DB_URL")
 	if dsn == "" {
@@ -25,31 +55,45 @@ func main() {
 		log.Fatal("Error connecting to DB:", err)
 	}
 
-	email := "snehil123@gmail.com"
-	password := "123456"
-
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	hash, err := bcrypt.GenerateFromPassword([]byte(*password), 10)
 	if err != nil {
 		log.Fatal("Error hashing password:", err)
 	}
 
+	verifiedAt := time.Now()
 	admin := models.User{
-		Name:     "Admin",
-		Email:    email,
-		Password: string(hash),
-		IsAdmin:  true,
+		Name:            *name,
+		Email:           *email,
+		Password:        string(hash),
+		EmailVerifiedAt: &verifiedAt,
 	}
 
 	var existing models.User
-	if err := db.Where("email = ?", email).First(&existing).Error; err == nil {
-		// User exists - update to admin
+	if err := db.Where("email = ?", *email).First(&existing).Error; err == nil {
+		// User exists - make them a super admin
 		db.Model(&existing).Updates(map[string]interface{}{
-			"password": string(hash),
-			"is_admin": true,
+			"password":          string(hash),
+			"no_password":       false,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", verifiedAt),
 		})
-		log.Printf("Updated existing user to admin: %s / %s\n", email, password)
+		admin = existing
+		log.Printf("Updated existing user to admin: %s\n", *email)
 	} else {
 		db.Create(&admin)
-		log.Printf("Created admin: %s / %s\n", email, password)
+		log.Printf("Created admin: %s\n", *email)
+	}
+	var role models.RoleAssignment
+	result := db.Where("user_id = ? AND role = ? AND operator_id IS NULL AND venue_id IS NULL", admin.ID, models.RoleSuperAdmin).
+		FirstOrCreate(&role, models.RoleAssignment{UserID: admin.ID, Role: models.RoleSuperAdmin})
+	if result.RowsAffected > 0 {
+		// No actor, so the audit log shows the grant came from the command line
+		db.Create(&models.AuditLog{
+			Action:       models.AuditRoleGranted,
+			TargetUserID: admin.ID,
+			Details:      map[string]interface{}{"role": role.Role, "assignment_id": role.ID, "source": "create-admin"},
+		})
+	}
+	if generated {
+		log.Printf("Generated password (shown once): %s\n", *password)
 	}
 }
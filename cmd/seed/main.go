@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/metadata"
 	"github.com/Snehil208001/BookMyShowApp/models"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
@@ -28,13 +31,14 @@ func main() {
 
 	log.Println("Seeding database...")
 
-	// 1. Create test user
+	// 1. Create test user, already verified so it can book straight away
+	verifiedAt := time.Now()
 	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), 10)
 	user := models.User{
-		Name:     "Test User",
-		Email:    "test@example.com",
-		Password: string(hash),
-		IsAdmin:  false,
+		Name:            "Test User",
+		Email:           "test@example.com",
+		Password:        string(hash),
+		EmailVerifiedAt: &verifiedAt,
 	}
 	if err := db.Where("email = ?", user.Email).First(&models.User{}).Error; err == gorm.ErrRecordNotFound {
 		db.Create(&user)
@@ -46,48 +50,107 @@ func main() {
 	// 2. Create admin user
 	adminHash, _ := bcrypt.GenerateFromPassword([]byte("admin123"), 10)
 	admin := models.User{
-		Name:     "Admin",
-		Email:    "admin@example.com",
-		Password: string(adminHash),
-		IsAdmin:  true,
+		Name:            "Admin",
+		Email:           "admin@example.com",
+		Password:        string(adminHash),
+		EmailVerifiedAt: &verifiedAt,
 	}
 	if err := db.Where("email = ?", admin.Email).First(&models.User{}).Error; err == gorm.ErrRecordNotFound {
 		db.Create(&admin)
+		db.Create(&models.RoleAssignment{UserID: admin.ID, Role: models.RoleSuperAdmin})
 		log.Println("Created admin: admin@example.com / admin123")
 	}
 
-	// 3. Create movies with posters (TMDB + reliable fallbacks for broken URLs)
-	movies := []models.Movie{
-		{Title: "Inception", Description: "A mind-bending thriller about dreams within dreams. A thief who steals corporate secrets through dream-sharing technology.", Duration: "2h 28m", Poster: "https://image.tmdb.org/t/p/w500/1E5baAaEse26fej7uHcjOgEE2t2.jpg"},
-		{Title: "The Dark Knight", Description: "Batman faces the Joker in Gotham City. Chaos and order collide in this epic superhero film.", Duration: "2h 32m", Poster: "https://m.media-amazon.com/images/M/MV5BMTMxNTMwODM0NF5BMl5BanBnXkFtZTcwODAyMTk2Mw@@._V1_SX300.jpg"},
-		{Title: "Interstellar", Description: "A team of explorers travel through a wormhole in space in search of a new home for humanity.", Duration: "2h 49m", Poster: "https://image.tmdb.org/t/p/w500/gEU2QniE6E77NI6lCU6MxlNBvIx.jpg"},
-		{Title: "Avengers: Endgame", Description: "The Avengers assemble once more to reverse Thanos' snap and restore the universe.", Duration: "3h 1m", Poster: "https://image.tmdb.org/t/p/w500/or06FN3Dka5tukK1e9sl16pB3iy.jpg"},
-		{Title: "Dune", Description: "A noble family becomes embroiled in a war for control of the galaxy's most valuable asset.", Duration: "2h 35m", Poster: "https://m.media-amazon.com/images/M/MV5BOTEwYWFjYmItZWJmNi00MGExLWI1MjktYzRiYjJkNzhiMWIxXkEyXkFqcGdeQXNuZXNodQ@@._V1_SX300.jpg"},
-		{Title: "Oppenheimer", Description: "The story of J. Robert Oppenheimer and his role in the development of the atomic bomb.", Duration: "3h 0m", Poster: "https://m.media-amazon.com/images/M/MV5BMDBmYTZjNjUtN2M1MS00MTQ2LTk2ODgtNzc2M2QyZGE5NTVjXkEyXkFqcGdeQXVyNzAwMjU2MTY@._V1_SX300.jpg"},
-		{Title: "Spider-Man: No Way Home", Description: "Peter Parker's identity is revealed to the world, leading to multiverse chaos.", Duration: "2h 28m", Poster: "https://image.tmdb.org/t/p/w500/1g0dhYtq4irTY1GPXvft6k4YLjm.jpg"},
-		{Title: "Top Gun: Maverick", Description: "After thirty years, Maverick is still pushing the envelope as a top naval aviator.", Duration: "2h 10m", Poster: "https://image.tmdb.org/t/p/w500/62HCnUTziyWcpDaBO2i1DX17ljH.jpg"},
+	// 3. Create movies from the metadata provider fixtures (METADATA_DIR, default testdata/metadata)
+	metadataDir := os.Getenv("METADATA_DIR")
+	if metadataDir == "" {
+		metadataDir = "testdata/metadata"
 	}
-	for _, m := range movies {
+	provider := metadata.NewFileProvider(metadataDir)
+	importer := metadata.NewImporter(provider, db)
+	ctx := context.Background()
+	for _, externalID := range []string{
+		"tt1375666",  // Inception
+		"tt0468569",  // The Dark Knight
+		"tt0816692",  // Interstellar
+		"tt4154796",  // Avengers: Endgame
+		"tt1160419",  // Dune
+		"tt15398776", // Oppenheimer
+		"tt10872600", // Spider-Man: No Way Home
+		"tt1745960",  // Top Gun: Maverick
+	} {
+		md, err := provider.FetchMovie(ctx, externalID)
+		if err != nil {
+			log.Println("Skipping movie", externalID, "-", err)
+			continue
+		}
+		// Movies seeded before external IDs existed get linked rather than duplicated
 		var existing models.Movie
-		if db.Where("title = ?", m.Title).First(&existing).Error == gorm.ErrRecordNotFound {
-			db.Create(&m)
-			log.Println("Created movie:", m.Title)
-		} else {
-			db.Model(&existing).Update("poster", m.Poster)
+		if db.Where("title = ? AND COALESCE(external_id, '') = ''", md.Title).First(&existing).Error == nil {
+			importer.Link(ctx, &existing, externalID)
+			continue
+		}
+		movie, result, err := importer.Import(ctx, externalID)
+		if err != nil {
+			log.Println("Failed to import movie", externalID, "-", err)
+			continue
+		}
+		if result.Created {
+			log.Println("Created movie:", movie.Title)
 		}
 	}
 
-	// 4. Create venues
+	// 4. Create the region, cities, operators and venues
+	india := models.Region{Name: "India", Code: "IN", Timezone: "Asia/Kolkata", Currency: "INR", TaxProfile: &models.TaxProfile{Name: "GST", Rate: 18, Inclusive: true}, Enabled: true}
+	if db.Where("code = ?", india.Code).First(&india).Error == gorm.ErrRecordNotFound {
+		db.Create(&india)
+		log.Println("Created region:", india.Name)
+	}
+	cities := map[string]*models.City{
+		"Mumbai":    {Name: "Mumbai", State: "Maharashtra", Country: "India", Latitude: 19.076, Longitude: 72.8777},
+		"Delhi":     {Name: "Delhi", State: "Delhi", Country: "India", Latitude: 28.6139, Longitude: 77.209},
+		"Bangalore": {Name: "Bangalore", State: "Karnataka", Country: "India", Latitude: 12.9716, Longitude: 77.5946},
+	}
+	for _, city := range cities {
+		city.RegionID = &india.ID
+		city.Enabled = true
+		if db.Where("name = ?", city.Name).First(city).Error == gorm.ErrRecordNotFound {
+			db.Create(city)
+			log.Println("Created city:", city.Name)
+		} else if city.RegionID == nil {
+			db.Model(city).Update("region_id", india.ID)
+		}
+	}
+	operators := map[string]*models.Operator{
+		"PVR Cinemas": {Name: "PVR", Branding: models.Branding{PrimaryColor: "#FFCB05", Website: "https://www.pvrcinemas.com"}},
+		"INOX":        {Name: "INOX", Branding: models.Branding{PrimaryColor: "#1A3D7C", Website: "https://www.inoxmovies.com"}},
+		"Cinepolis":   {Name: "Cinepolis", Branding: models.Branding{PrimaryColor: "#0C2F5C", Website: "https://www.cinepolisindia.com"}},
+	}
+	for _, operator := range operators {
+		if db.Where("name = ?", operator.Name).First(operator).Error == gorm.ErrRecordNotFound {
+			db.Create(operator)
+			log.Println("Created operator:", operator.Name)
+		}
+	}
+	coordinate := func(f float64) *float64 { return &f }
 	venues := []models.Venue{
-		{Name: "PVR Cinemas", Location: "Mumbai"},
-		{Name: "INOX", Location: "Delhi"},
-		{Name: "Cinepolis", Location: "Bangalore"},
+		{Name: "PVR Cinemas", Location: "Mumbai", AddressLine1: "Phoenix Palladium, Lower Parel", Locality: "Lower Parel", PostalCode: "400013", Latitude: coordinate(18.9947), Longitude: coordinate(72.8258)},
+		{Name: "INOX", Location: "Delhi", AddressLine1: "Nehru Place", Locality: "Nehru Place", PostalCode: "110019", Latitude: coordinate(28.5494), Longitude: coordinate(77.2517)},
+		{Name: "Cinepolis", Location: "Bangalore", AddressLine1: "Royal Meenakshi Mall, Bannerghatta Road", Locality: "Hulimavu", PostalCode: "560076", Latitude: coordinate(12.8776), Longitude: coordinate(77.5957)},
 	}
 	for _, v := range venues {
+		v.CityID = &cities[v.Location].ID
+		v.OperatorID = &operators[v.Name].ID
 		var existing models.Venue
 		if db.Where("name = ? AND location = ?", v.Name, v.Location).First(&existing).Error == gorm.ErrRecordNotFound {
 			db.Create(&v)
 			log.Println("Created venue:", v.Name, "-", v.Location)
+		} else if existing.CityID == nil {
+			// Venues seeded before addresses existed get placed
+			db.Model(&existing).Updates(models.Venue{AddressLine1: v.AddressLine1, Locality: v.Locality, PostalCode: v.PostalCode, CityID: v.CityID, Latitude: v.Latitude, Longitude: v.Longitude})
+		}
+		if existing.ID != 0 && existing.OperatorID == nil {
+			db.Model(&existing).Update("operator_id", *v.OperatorID)
 		}
 	}
 
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ExportMyData downloads everything we keep about the user as a zip of JSON files: their profile and linked
+// providers, orders, reviews, sessions, notifications and movie interests
+func ExportMyData(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+
+	var identities []models.Identity
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&identities)
+	var orders []models.Order
+	initializers.Db.Where("user_id = ?", userDetails.ID).Preload("Seats").Order("created_at").Find(&orders)
+	var reviews []models.Review
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&reviews)
+	var sessions []models.Session
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&sessions)
+	var notifications []models.Notification
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&notifications)
+	var interests []models.MovieInterest
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&interests)
+
+	sessionData := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		sessionData = append(sessionData, gin.H{
+			"user_agent":   s.UserAgent,
+			"ip":           s.IP,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"expires_at":   s.ExpiresAt,
+			"revoked_at":   s.RevokedAt,
+		})
+	}
+
+	now := time.Now()
+	var archive bytes.Buffer
+	err := helpers.WriteExportArchive(&archive, map[string]interface{}{
+		"export":          gin.H{"user_id": userDetails.ID, "generated_at": now},
+		"profile":         gin.H{"user": userDetails, "identities": identities},
+		"orders":          buildOrderResponses(orders),
+		"reviews":         reviews,
+		"sessions":        sessionData,
+		"notifications":   notifications,
+		"movie_interests": interests,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export your data"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=bookmyshow-export-%d-%s.zip", userDetails.ID, now.Format("20060102")))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// DeleteMe schedules the user's account for deletion after the cooling-off period. Their other devices are
+// signed out; until the deletion goes through they can log in and cancel it. Orders are kept for accounting,
+// everything else personal is scrubbed (see helpers.AnonymizeAccount).
+func DeleteMe(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	c.ShouldBindJSON(&body)
+	// Accounts created with a provider have no password to confirm with
+	if !userDetails.NoPassword && bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(body.Password)) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+		return
+	}
+	if userDetails.DeletionScheduledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your account is already scheduled for deletion", "deletion_scheduled_at": userDetails.DeletionScheduledAt})
+		return
+	}
+	var roles int64
+	initializers.Db.Model(&models.RoleAssignment{}).Where("user_id = ?", userDetails.ID).Count(&roles)
+	if roles > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ask an admin to remove your staff roles before deleting your account"})
+		return
+	}
+
+	scheduled := time.Now().Add(helpers.AccountDeletionCoolingOff())
+	if err := initializers.Db.Model(&userDetails).Update("deletion_scheduled_at", scheduled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+	revokeTokens(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? AND family_id <> ?", userDetails.ID, claims.SessionID)
+	})
+	helpers.Notify(userDetails, "Your account will be deleted",
+		"Your account and personal data will be deleted on "+scheduled.Format("2 January 2006")+
+			". Your order records are kept for accounting. Changed your mind? Log in and cancel before then.")
+	c.JSON(http.StatusAccepted, gin.H{"message": "Account scheduled for deletion", "deletion_scheduled_at": scheduled})
+}
+
+// CancelAccountDeletion keeps an account that was scheduled for deletion
+func CancelAccountDeletion(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	if userDetails.DeletionScheduledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your account is not scheduled for deletion"})
+		return
+	}
+	if err := initializers.Db.Model(&userDetails).Update("deletion_scheduled_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	helpers.Notify(userDetails, "Your account will not be deleted", "You cancelled the deletion of your account, it stays as it is.")
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
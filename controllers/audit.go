@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// recordAudit logs an action the request's user took on the target's account. A failure to record
+// is logged rather than undoing the action, which has already happened.
+func recordAudit(c *gin.Context, action string, targetUserID uint, details map[string]interface{}) {
+	entry := models.AuditLog{Action: action, TargetUserID: targetUserID, Details: details, IP: c.ClientIP()}
+	if user, ok := c.Get("user"); ok {
+		actor := user.(models.User)
+		entry.ActorID = &actor.ID
+	}
+	if err := initializers.Db.Create(&entry).Error; err != nil {
+		log.Printf("[audit] failed to record %s on user %d: %v\n", action, targetUserID, err)
+	}
+}
+
+// GetAuditLog lists the latest audit entries, narrowed with ?user_id (the target), ?actor_id and ?action
+func GetAuditLog(c *gin.Context) {
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil {
+		offset = o
+	}
+	query := initializers.Db.Model(&models.AuditLog{})
+	if v := c.Query("user_id"); v != "" {
+		query = query.Where("target_user_id = ?", v)
+	}
+	if v := c.Query("actor_id"); v != "" {
+		query = query.Where("actor_id = ?", v)
+	}
+	if v := c.Query("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+	var total int64
+	query.Count(&total)
+
+	var entries []models.AuditLog
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the audit log"})
+		return
+	}
+	nextOffset := offset + limit
+	if nextOffset >= int(total) {
+		nextOffset = -1
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total": total, "next_offset": nextOffset})
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Snehil208001/BookMyShowApp/catalog"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/gin-gonic/gin"
+)
+
+// Largest catalog file accepted in one import
+const maxCatalogUpload = 10 << 20
+
+// ImportCatalog upserts movies, venues, screens or showtimes from an uploaded CSV or JSON file.
+// The file comes as multipart "file" or as the raw body, with ?format= when the name doesn't say.
+func ImportCatalog(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogUpload)
+
+	format := c.Query("format")
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded under 'file' key"})
+			return
+		}
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error opening file"})
+			return
+		}
+		defer f.Close()
+		reader = f
+		if format == "" {
+			format = catalog.FormatFromFilename(fileHeader.Filename)
+		}
+	} else if format == "" && c.ContentType() == "application/json" {
+		format = catalog.FormatJSON
+	} else if format == "" && c.ContentType() == "text/csv" {
+		format = catalog.FormatCSV
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	report, err := catalog.Import(initializers.Db, c.Param("kind"), format, reader, dryRun)
+	switch {
+	case errors.Is(err, catalog.ErrRowsFailed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+	case errors.Is(err, catalog.ErrUnknownKind), errors.Is(err, catalog.ErrUnknownFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil && report == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save import", "report": report})
+	default:
+		c.JSON(http.StatusOK, gin.H{"report": report})
+	}
+}
+
+// ExportCatalog downloads the current catalog in the same shape ImportCatalog takes
+func ExportCatalog(c *gin.Context) {
+	kind := c.Param("kind")
+	format := c.DefaultQuery("format", catalog.FormatJSON)
+
+	var body strings.Builder
+	err := catalog.Export(initializers.Db, kind, format, &body)
+	if errors.Is(err, catalog.ErrUnknownKind) || errors.Is(err, catalog.ErrUnknownFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export catalog"})
+		return
+	}
+	contentType := "application/json"
+	if format == catalog.FormatCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", kind, format))
+	c.Data(http.StatusOK, contentType, []byte(body.String()))
+}
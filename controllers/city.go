@@ -0,0 +1,418 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errCityUnavailable = errors.New("City is not available")
+
+// requestCity is the city a listing is scoped to: ?city_id= if given, otherwise the logged in
+// user's preferred city. nil means no city was picked, so listings cover every open city.
+// A preferred city that has since been disabled is ignored; asking for one by ID is an error.
+func requestCity(c *gin.Context) (*models.City, int, error) {
+	var cityID uint
+	explicit := c.Query("city_id") != ""
+	if explicit {
+		id, err := strconv.ParseUint(c.Query("city_id"), 10, 64)
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.New("Invalid city_id")
+		}
+		cityID = uint(id)
+	} else if user, ok := c.Get("user"); ok && user.(models.User).PreferredCityID != nil {
+		cityID = *user.(models.User).PreferredCityID
+	} else {
+		return nil, 0, nil
+	}
+	var city models.City
+	if err := initializers.Db.Preload("Region").First(&city, cityID).Error; err != nil {
+		if explicit {
+			return nil, http.StatusNotFound, errors.New("City not found")
+		}
+		return nil, 0, nil
+	}
+	if !city.IsAvailable() {
+		if explicit {
+			return nil, http.StatusNotFound, errCityUnavailable
+		}
+		return nil, 0, nil
+	}
+	return &city, 0, nil
+}
+
+// openCityIDs selects the cities that are enabled along with their region
+func openCityIDs() *gorm.DB {
+	return initializers.Db.Model(&models.City{}).Select("cities.id").
+		Joins("LEFT JOIN regions ON regions.id = cities.region_id AND regions.deleted_at IS NULL").
+		Where("cities.enabled AND (regions.id IS NULL OR regions.enabled)")
+}
+
+// venueCityScope narrows a query on venues to the city, or with no city to venues in open cities
+// and venues not yet placed in a city
+func venueCityScope(city *models.City) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if city != nil {
+			return db.Where("venues.city_id = ?", city.ID)
+		}
+		return db.Where("venues.city_id IS NULL OR venues.city_id IN (?)", openCityIDs())
+	}
+}
+
+// showTimeCityScope narrows a query on show_times to venues within venueCityScope
+func showTimeCityScope(city *models.City) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		venues := initializers.Db.Model(&models.Venue{}).Select("venues.id").Scopes(venueCityScope(city))
+		return db.Where("show_times.venue_id IN (?)", venues)
+	}
+}
+
+// movieCityScope narrows a query on movies to those with showtimes in the city. Movies that aren't
+// scheduled anywhere yet, like announcements, are national and always included.
+func movieCityScope(city *models.City) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if city == nil {
+			return db
+		}
+		inCity := initializers.Db.Model(&models.ShowTime{}).Select("show_times.movie_id").Scopes(showTimeCityScope(city))
+		scheduled := initializers.Db.Model(&models.ShowTime{}).Select("show_times.movie_id")
+		return db.Where("movies.id IN (?) OR movies.id NOT IN (?)", inCity, scheduled)
+	}
+}
+
+// createDisabledAware creates a city or region. GORM leaves out false for a column with a default,
+// so a record created disabled is switched off straight after.
+func createDisabledAware(record interface{}, enabled bool) error {
+	return initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(record).Error; err != nil {
+			return err
+		}
+		if enabled {
+			return nil
+		}
+		return tx.Model(record).Update("enabled", false).Error
+	})
+}
+
+// CityResponse is a city with its settings worked out
+type CityResponse struct {
+	models.City
+	Settings models.CitySettings `json:"settings"`
+}
+
+// GetCities lists the open cities, optionally in one region (?region=<code>)
+func GetCities(c *gin.Context) {
+	query := initializers.Db.Preload("Region").Where("cities.id IN (?)", openCityIDs()).Order("cities.name")
+	if code := c.Query("region"); code != "" {
+		query = query.Joins("JOIN regions ON regions.id = cities.region_id").Where("LOWER(regions.code) = LOWER(?)", code)
+	}
+	var cities []models.City
+	if err := query.Find(&cities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load cities"})
+		return
+	}
+	response := make([]CityResponse, len(cities))
+	for i, city := range cities {
+		response[i] = CityResponse{City: city, Settings: city.Settings()}
+	}
+	c.JSON(http.StatusOK, gin.H{"cities": response})
+}
+
+// checkTimezone makes sure a timezone, if given, is a real IANA name
+func checkTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return errors.New("Unknown timezone " + name)
+	}
+	return nil
+}
+
+type CityRequestBody struct {
+	Name      string  `json:"name" validate:"required"`
+	State     string  `json:"state"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"min=-180,max=180"`
+
+	RegionID   *uint              `json:"region_id"`
+	Timezone   string             `json:"timezone"`
+	Currency   string             `json:"currency" validate:"omitempty,iso4217"`
+	TaxProfile *models.TaxProfile `json:"tax_profile"`
+	Enabled    *bool              `json:"enabled"` // Defaults to true
+}
+
+func CreateCity(c *gin.Context) {
+	var body CityRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if err := checkTimezone(body.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.RegionID != nil {
+		if err := initializers.Db.First(&models.Region{}, *body.RegionID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+			return
+		}
+	}
+	var count int64
+	initializers.Db.Model(&models.City{}).
+		Where("LOWER(name) = LOWER(?) AND LOWER(state) = LOWER(?) AND LOWER(country) = LOWER(?)", body.Name, body.State, body.Country).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "City already exists"})
+		return
+	}
+	city := models.City{
+		Name:       body.Name,
+		State:      body.State,
+		Country:    body.Country,
+		Latitude:   body.Latitude,
+		Longitude:  body.Longitude,
+		RegionID:   body.RegionID,
+		Timezone:   body.Timezone,
+		Currency:   body.Currency,
+		TaxProfile: body.TaxProfile,
+		Enabled:    body.Enabled == nil || *body.Enabled,
+	}
+	if err := createDisabledAware(&city, city.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create city"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"city": city})
+}
+
+type UpdateCityBody struct {
+	Name       *string            `json:"name" validate:"omitempty,min=1"`
+	State      *string            `json:"state"`
+	Country    *string            `json:"country"`
+	Latitude   *float64           `json:"latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude  *float64           `json:"longitude" validate:"omitempty,min=-180,max=180"`
+	RegionID   *uint              `json:"region_id"`
+	Timezone   *string            `json:"timezone"`
+	Currency   *string            `json:"currency" validate:"omitempty,iso4217"`
+	TaxProfile *models.TaxProfile `json:"tax_profile"`
+	Enabled    *bool              `json:"enabled"`
+}
+
+// UpdateCity edits a city. Disabling it hides the city and its venues from every listing.
+func UpdateCity(c *gin.Context) {
+	var body UpdateCityBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var city models.City
+	if err := initializers.Db.First(&city, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+		return
+	}
+	if body.Timezone != nil {
+		if err := checkTimezone(*body.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		city.Timezone = *body.Timezone
+	}
+	if body.RegionID != nil {
+		if err := initializers.Db.First(&models.Region{}, *body.RegionID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+			return
+		}
+		city.RegionID = body.RegionID
+	}
+	if body.Name != nil {
+		city.Name = *body.Name
+	}
+	if body.State != nil {
+		city.State = *body.State
+	}
+	if body.Country != nil {
+		city.Country = *body.Country
+	}
+	if body.Latitude != nil {
+		city.Latitude = *body.Latitude
+	}
+	if body.Longitude != nil {
+		city.Longitude = *body.Longitude
+	}
+	if body.Currency != nil {
+		city.Currency = *body.Currency
+	}
+	if body.TaxProfile != nil {
+		city.TaxProfile = body.TaxProfile
+	}
+	if body.Enabled != nil {
+		city.Enabled = *body.Enabled
+	}
+	if err := initializers.Db.Omit("Region").Save(&city).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update city"})
+		return
+	}
+	initializers.Db.Preload("Region").First(&city, city.ID)
+	c.JSON(http.StatusOK, gin.H{"city": CityResponse{City: city, Settings: city.Settings()}})
+}
+
+func GetRegions(c *gin.Context) {
+	var regions []models.Region
+	initializers.Db.Where("enabled").Order("name").Find(&regions)
+	c.JSON(http.StatusOK, gin.H{"regions": regions})
+}
+
+type RegionRequestBody struct {
+	Name       string             `json:"name" validate:"required"`
+	Code       string             `json:"code" validate:"required,max=10"`
+	Timezone   string             `json:"timezone" validate:"required"`
+	Currency   string             `json:"currency" validate:"required,iso4217"`
+	TaxProfile *models.TaxProfile `json:"tax_profile"`
+	Enabled    *bool              `json:"enabled"` // Defaults to true
+}
+
+func CreateRegion(c *gin.Context) {
+	var body RegionRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if err := checkTimezone(body.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var count int64
+	initializers.Db.Model(&models.Region{}).Where("LOWER(code) = LOWER(?)", body.Code).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Region code already in use"})
+		return
+	}
+	region := models.Region{
+		Name:       body.Name,
+		Code:       body.Code,
+		Timezone:   body.Timezone,
+		Currency:   body.Currency,
+		TaxProfile: body.TaxProfile,
+		Enabled:    body.Enabled == nil || *body.Enabled,
+	}
+	if err := createDisabledAware(&region, region.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create region"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"region": region})
+}
+
+type UpdateRegionBody struct {
+	Name       *string            `json:"name" validate:"omitempty,min=1"`
+	Timezone   *string            `json:"timezone"`
+	Currency   *string            `json:"currency" validate:"omitempty,iso4217"`
+	TaxProfile *models.TaxProfile `json:"tax_profile"`
+	Enabled    *bool              `json:"enabled"`
+}
+
+// UpdateRegion edits a region. Disabling it hides all of its cities.
+func UpdateRegion(c *gin.Context) {
+	var body UpdateRegionBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var region models.Region
+	if err := initializers.Db.First(&region, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+		return
+	}
+	if body.Timezone != nil {
+		if *body.Timezone == "" || checkTimezone(*body.Timezone) != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone " + *body.Timezone})
+			return
+		}
+		region.Timezone = *body.Timezone
+	}
+	if body.Name != nil {
+		region.Name = *body.Name
+	}
+	if body.Currency != nil {
+		region.Currency = *body.Currency
+	}
+	if body.TaxProfile != nil {
+		region.TaxProfile = body.TaxProfile
+	}
+	if body.Enabled != nil {
+		region.Enabled = *body.Enabled
+	}
+	if err := initializers.Db.Save(&region).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update region"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"region": region})
+}
+
+type PreferredCityBody struct {
+	CityID *uint `json:"city_id"` // null clears the preference
+}
+
+// preferableCity loads a city a user can pick as their preferred one
+func preferableCity(id uint) (*models.City, int, error) {
+	var city models.City
+	if err := initializers.Db.Preload("Region").First(&city, id).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("City not found")
+	}
+	if !city.IsAvailable() {
+		return nil, http.StatusBadRequest, errCityUnavailable
+	}
+	return &city, 0, nil
+}
+
+// SetPreferredCity picks the city the user's listings are scoped to
+func SetPreferredCity(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body PreferredCityBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var city *models.City
+	if body.CityID != nil {
+		found, status, err := preferableCity(*body.CityID)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		city = found
+	}
+	if err := initializers.Db.Model(&userDetails).Update("preferred_city_id", body.CityID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferred city"})
+		return
+	}
+	if city == nil {
+		c.JSON(http.StatusOK, gin.H{"city": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"city": CityResponse{City: *city, Settings: city.Settings()}})
+}
@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/Snehil208001/BookMyShowApp/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func isMediaType(t string) bool {
+	for _, mt := range models.MediaTypes {
+		if mt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaFromForm reads the optional gallery fields sent alongside an upload. Sizes come from processing.
+func mediaFromForm(c *gin.Context) (models.MovieMedia, error) {
+	media := models.MovieMedia{
+		Type:     c.DefaultPostForm("type", models.MediaPoster),
+		Language: c.PostForm("language"),
+	}
+	if !isMediaType(media.Type) {
+		return media, errors.New("Invalid media type")
+	}
+	if order := c.PostForm("sort_order"); order != "" {
+		n, err := strconv.Atoi(order)
+		if err != nil {
+			return media, errors.New("Invalid sort_order")
+		}
+		media.SortOrder = n
+	}
+	// Without an explicit flag a poster upload becomes the primary poster, like before the gallery existed
+	primary := c.PostForm("is_primary")
+	media.IsPrimary = primary == "true" || (primary == "" && media.Type == models.MediaPoster)
+	return media, nil
+}
+
+// saveMovieMedia stores the media and, when it is primary, demotes the old primary of the same type, size
+// and format and mirrors it on the movie. Processed uploads are promoted together with their other variants.
+// A new primary poster replaces every earlier one, and files of replaced uploads are deleted.
+func saveMovieMedia(ctx context.Context, movie *models.Movie, media *models.MovieMedia) error {
+	var replaced []string
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(media).Error; err != nil {
+			return err
+		}
+		if !media.IsPrimary {
+			return nil
+		}
+		var err error
+		if media.UploadKey != "" {
+			replaced, err = promoteUpload(tx, movie, media.Type, media.UploadKey)
+			return err
+		}
+		if media.Type == models.MediaPoster {
+			replaced, err = retirePrimaryPosters(tx, movie.ID, tx.Where("id <> ?", media.ID))
+		} else {
+			err = tx.Model(&models.MovieMedia{}).
+				Where("movie_id = ? AND type = ? AND size = ? AND format = ? AND id <> ?", movie.ID, media.Type, media.Size, media.Format, media.ID).
+				Update("is_primary", false).Error
+		}
+		if err != nil {
+			return err
+		}
+		switch media.Type {
+		case models.MediaPoster:
+			// A linked poster has no processed sizes
+			movie.Poster = media.URL
+			movie.PosterVariants = nil
+		case models.MediaTrailer:
+			movie.TrailerURL = media.URL
+		default:
+			return nil
+		}
+		return tx.Save(movie).Error
+	})
+	if err == nil {
+		helpers.DeleteFiles(ctx, replaced)
+	}
+	return err
+}
+
+// saveMovieVariants stores every variant of one processed upload
+func saveMovieVariants(ctx context.Context, movie *models.Movie, variants []models.MovieMedia) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	var replaced []string
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&variants).Error; err != nil {
+			return err
+		}
+		if !variants[0].IsPrimary {
+			return nil
+		}
+		var err error
+		replaced, err = promoteUpload(tx, movie, variants[0].Type, variants[0].UploadKey)
+		return err
+	})
+	if err == nil {
+		helpers.DeleteFiles(ctx, replaced)
+	}
+	return err
+}
+
+// promoteUpload makes every variant of one upload the primary for its type, replacing all other primaries
+// of that type. For posters the movie gets the card JPEG as its poster and every variant URL, and the
+// storage keys of replaced poster uploads are returned for deletion.
+func promoteUpload(tx *gorm.DB, movie *models.Movie, mediaType, uploadKey string) ([]string, error) {
+	var replaced []string
+	var err error
+	if mediaType == models.MediaPoster {
+		replaced, err = retirePrimaryPosters(tx, movie.ID, tx.Where("upload_key <> ?", uploadKey))
+	} else {
+		err = tx.Model(&models.MovieMedia{}).
+			Where("movie_id = ? AND type = ? AND upload_key <> ?", movie.ID, mediaType, uploadKey).
+			Update("is_primary", false).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	var variants []models.MovieMedia
+	if err := tx.Where("movie_id = ? AND upload_key = ?", movie.ID, uploadKey).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.MovieMedia{}).
+		Where("movie_id = ? AND upload_key = ?", movie.ID, uploadKey).
+		Update("is_primary", true).Error; err != nil {
+		return nil, err
+	}
+	if mediaType != models.MediaPoster {
+		return nil, nil
+	}
+	movie.Poster, movie.PosterVariants = posterFromVariants(variants)
+	return replaced, tx.Save(movie).Error
+}
+
+// retirePrimaryPosters steps down the movie's primary posters other than the new one. Uploaded posters are
+// removed from the gallery and their storage keys returned, so the files can go once the change commits.
+// Linked posters only lose their primary flag.
+func retirePrimaryPosters(tx *gorm.DB, movieID uint, exclude *gorm.DB) ([]string, error) {
+	query := tx.Where("movie_id = ? AND type = ? AND is_primary = ?", movieID, models.MediaPoster, true)
+	if exclude != nil {
+		query = query.Where(exclude)
+	}
+	var old []models.MovieMedia
+	if err := query.Find(&old).Error; err != nil {
+		return nil, err
+	}
+	var keys []string
+	var uploaded, linked []uint
+	for _, m := range old {
+		if m.StorageKey != "" {
+			keys = append(keys, m.StorageKey)
+			uploaded = append(uploaded, m.ID)
+		} else {
+			linked = append(linked, m.ID)
+		}
+	}
+	if len(uploaded) > 0 {
+		if err := tx.Unscoped().Delete(&models.MovieMedia{}, uploaded).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(linked) > 0 {
+		if err := tx.Model(&models.MovieMedia{}).Where("id IN ?", linked).Update("is_primary", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// posterFromVariants picks the URL stored as the movie's poster, the card JPEG when there is one,
+// and maps every variant by size and format
+func posterFromVariants(variants []models.MovieMedia) (string, map[string]map[string]string) {
+	poster := ""
+	urls := map[string]map[string]string{}
+	for _, v := range variants {
+		if urls[v.Size] == nil {
+			urls[v.Size] = map[string]string{}
+		}
+		urls[v.Size][v.Format] = v.URL
+		if v.Format == helpers.ImageFormatJPEG && (poster == "" || v.Size == "card") {
+			poster = v.URL
+		}
+	}
+	return poster, urls
+}
+
+func GetMovieMedia(c *gin.Context) {
+	query := initializers.Db.Where("movie_id = ?", c.Param("id"))
+	if t := c.Query("type"); t != "" {
+		query = query.Where("type = ?", t)
+	}
+	if lang := c.Query("language"); lang != "" {
+		// Language-neutral media suits every language
+		query = query.Where("language = ? OR language = ''", lang)
+	}
+	var media []models.MovieMedia
+	query.Order("type, is_primary desc, sort_order").Find(&media)
+	c.JSON(http.StatusOK, gin.H{"media": media})
+}
+
+type MovieMediaBody struct {
+	Type      string `json:"type" validate:"required"`
+	URL       string `json:"url" validate:"required,url"`
+	Size      string `json:"size"`
+	Language  string `json:"language"`
+	SortOrder int    `json:"sort_order"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+// AddMovieMedia links media hosted elsewhere, such as a trailer on a video site
+func AddMovieMedia(c *gin.Context) {
+	var body MovieMediaBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if !isMediaType(body.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media type"})
+		return
+	}
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	media := models.MovieMedia{
+		MovieID:   movie.ID,
+		Type:      body.Type,
+		URL:       body.URL,
+		Size:      body.Size,
+		Language:  body.Language,
+		SortOrder: body.SortOrder,
+		IsPrimary: body.IsPrimary,
+	}
+	if err := saveMovieMedia(c.Request.Context(), &movie, &media); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save media"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"media": media})
+}
+
+type UpdateMovieMediaBody struct {
+	Language  *string `json:"language"`
+	SortOrder *int    `json:"sort_order"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+func UpdateMovieMedia(c *gin.Context) {
+	var body UpdateMovieMediaBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	var media models.MovieMedia
+	if err := initializers.Db.Where("movie_id = ?", movie.ID).First(&media, c.Param("mediaId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return
+	}
+	if body.Language != nil {
+		media.Language = *body.Language
+	}
+	if body.SortOrder != nil {
+		media.SortOrder = *body.SortOrder
+	}
+	if body.IsPrimary != nil {
+		media.IsPrimary = *body.IsPrimary
+	}
+	if err := saveMovieMedia(c.Request.Context(), &movie, &media); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update media"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media": media})
+}
+
+func DeleteMovieMedia(c *gin.Context) {
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	var media models.MovieMedia
+	if err := initializers.Db.Where("movie_id = ?", movie.ID).First(&media, c.Param("mediaId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return
+	}
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&media).Error; err != nil {
+			return err
+		}
+		// Don't leave the movie pointing at media that is gone
+		switch {
+		case media.Type == models.MediaPoster && movie.Poster == media.URL:
+			movie.Poster = ""
+			movie.PosterVariants = nil
+		case media.Type == models.MediaPoster && movie.PosterVariants[media.Size][media.Format] == media.URL:
+			delete(movie.PosterVariants[media.Size], media.Format)
+		case media.Type == models.MediaTrailer && movie.TrailerURL == media.URL:
+			movie.TrailerURL = ""
+		default:
+			return nil
+		}
+		return tx.Save(&movie).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		return
+	}
+	if media.StorageKey != "" {
+		helpers.DeleteFiles(c.Request.Context(), []string{media.StorageKey})
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted"})
+}
+
+// Signed links are for staff checking a stored file, so they don't need to last long
+const maxSignedURLExpiry = time.Hour
+
+// GetMovieMediaSignedURL returns a short-lived link to an uploaded file, for stores that keep files private
+func GetMovieMediaSignedURL(c *gin.Context) {
+	expiry := 15 * time.Minute
+	if e := c.Query("expires"); e != "" {
+		d, err := time.ParseDuration(e)
+		if err != nil || d <= 0 || d > maxSignedURLExpiry {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires must be a duration up to 1h, e.g. 15m"})
+			return
+		}
+		expiry = d
+	}
+	var media models.MovieMedia
+	if err := initializers.Db.Where("movie_id = ?", c.Param("id")).First(&media, c.Param("mediaId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return
+	}
+	if media.StorageKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Linked media has no stored file to sign"})
+		return
+	}
+	url, err := initializers.Blobs.SignedURL(c.Request.Context(), media.StorageKey, expiry)
+	if errors.Is(err, storage.ErrNotSupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Signed URLs are not supported by the " + initializers.Blobs.Name() + " storage backend"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign URL"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"url":        url,
+		"expires_at": time.Now().Add(expiry),
+	})
+}
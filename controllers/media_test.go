@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+)
+
+// formContext is a request context carrying the given form fields
+func formContext(fields url.Values) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fields.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c
+}
+
+func TestMediaFromForm_Defaults(t *testing.T) {
+	// A bare upload is the primary poster, as before the gallery existed
+	media, err := mediaFromForm(formContext(url.Values{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if media.Type != models.MediaPoster || !media.IsPrimary {
+		t.Errorf("expected a primary poster, got %+v", media)
+	}
+
+	media, err = mediaFromForm(formContext(url.Values{"type": {models.MediaStill}, "language": {"hi"}, "sort_order": {"3"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if media.Type != models.MediaStill || media.IsPrimary || media.Language != "hi" || media.SortOrder != 3 {
+		t.Errorf("unexpected still: %+v", media)
+	}
+
+	media, _ = mediaFromForm(formContext(url.Values{"is_primary": {"false"}}))
+	if media.IsPrimary {
+		t.Error("is_primary=false should keep a poster out of the primary slot")
+	}
+}
+
+func TestMediaFromForm_RejectsInvalidFields(t *testing.T) {
+	for name, fields := range map[string]url.Values{
+		"type":       {"type": {"gif"}},
+		"sort order": {"sort_order": {"first"}},
+	} {
+		if _, err := mediaFromForm(formContext(fields)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMovieMediaBody_Validation(t *testing.T) {
+	if err := validate.Struct(MovieMediaBody{Type: models.MediaTrailer, URL: "https://videos.example.com/watch?v=1"}); err != nil {
+		t.Errorf("a linked trailer should pass: %v", err)
+	}
+	if err := validate.Struct(MovieMediaBody{Type: models.MediaTrailer, URL: "not a url"}); err == nil {
+		t.Error("a link without a valid URL should fail validation")
+	}
+}
+
+func TestPosterFromVariants(t *testing.T) {
+	variants := []models.MovieMedia{
+		{Size: "thumbnail", Format: helpers.ImageFormatJPEG, URL: "thumb.jpg"},
+		{Size: "thumbnail", Format: helpers.ImageFormatWebP, URL: "thumb.webp"},
+		{Size: "card", Format: helpers.ImageFormatWebP, URL: "card.webp"},
+		{Size: "card", Format: helpers.ImageFormatJPEG, URL: "card.jpg"},
+		{Size: "hero", Format: helpers.ImageFormatJPEG, URL: "hero.jpg"},
+	}
+	poster, urls := posterFromVariants(variants)
+	if poster != "card.jpg" {
+		t.Errorf("expected the card JPEG as the poster, got %q", poster)
+	}
+	if urls["thumbnail"]["webp"] != "thumb.webp" || urls["hero"]["jpeg"] != "hero.jpg" || len(urls) != 3 {
+		t.Errorf("unexpected variant URLs: %v", urls)
+	}
+
+	// Without a card size any JPEG will do
+	if poster, _ := posterFromVariants(variants[:2]); poster != "thumb.jpg" {
+		t.Errorf("expected the thumbnail JPEG as the poster, got %q", poster)
+	}
+}
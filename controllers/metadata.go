@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/metadata"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ExternalIDBody struct {
+	ExternalID string `json:"external_id" validate:"required"`
+}
+
+func metadataImporter() *metadata.Importer {
+	return metadata.NewImporter(initializers.Metadata, initializers.Db)
+}
+
+func metadataError(c *gin.Context, err error) {
+	if errors.Is(err, metadata.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch movie metadata", "details": err.Error()})
+}
+
+// ImportMovieMetadata creates a movie from the metadata provider, or refreshes the one already imported
+func ImportMovieMetadata(c *gin.Context) {
+	var body ExternalIDBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	movie, result, err := metadataImporter().Import(c.Request.Context(), body.ExternalID)
+	if err != nil {
+		metadataError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"movie": movie, "sync": result})
+}
+
+// RefreshMovieMetadata re-syncs one movie. Passing external_id links the movie to a provider entry first.
+func RefreshMovieMetadata(c *gin.Context) {
+	var body struct {
+		ExternalID string `json:"external_id"`
+	}
+	// Body is optional
+	_ = c.ShouldBindJSON(&body)
+
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	importer := metadataImporter()
+	var result *metadata.SyncResult
+	var err error
+	switch {
+	case body.ExternalID != "":
+		result, err = importer.Link(c.Request.Context(), &movie, body.ExternalID)
+	case movie.ExternalID != "":
+		result, err = importer.Refresh(c.Request.Context(), &movie)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Movie has no external ID, pass external_id to link it"})
+		return
+	}
+	if err != nil {
+		metadataError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"movie": movie, "sync": result})
+}
+
+// ResyncMetadata refreshes every movie linked to the provider
+func ResyncMetadata(c *gin.Context) {
+	results := metadataImporter().ResyncAll(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -1,16 +1,30 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/Snehil208001/BookMyShowApp/helpers"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
 )
 
+// GetAllMovies lists movies, scoped to the request's city (see requestCity) and optionally a radius
 func GetAllMovies(c *gin.Context) {
+	city, status, err := requestCity(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 	var movies []models.Movie
 	limit := 5
 	l := c.Query("limit")
@@ -33,10 +47,41 @@ func GetAllMovies(c *gin.Context) {
 	if n != "" {
 		name = n
 	}
-	query := initializers.Db.Model(&models.Movie{})
+	query := initializers.Db.Model(&models.Movie{}).Scopes(movieCityScope(city))
 	if name != "" {
 		query = query.Where("title ILIKE ?", "%"+name+"%") // ILIKE for case-insensitive search
 	}
+	languages := requestLanguages(c)
+	if len(languages) > 0 {
+		query = query.Scopes(movieLanguageScope(languages))
+	}
+	// Filter by lifecycle stage, archived movies are hidden unless asked for
+	stage := c.Query("status")
+	switch {
+	case stage == "all":
+	case stage == "":
+		query = query.Scopes(models.MovieStageScope(time.Now(), models.StageAnnounced, models.StagePreBooking, models.StageNowShowing))
+	case isMovieStage(stage):
+		query = query.Scopes(models.MovieStageScope(time.Now(), stage))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+		return
+	}
+	// Only movies with showtimes at venues within the radius
+	geo, err := parseGeoFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if geo != nil {
+		nearby, err := venuesWithin(*geo, city)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search venues"})
+			return
+		}
+		showing := initializers.Db.Model(&models.ShowTime{}).Select("movie_id").Where("venue_id IN ?", venueIDs(nearby))
+		query = query.Where("id IN (?)", showing)
+	}
 	// Now my query will have movies with particular name only, now we will add pagination on that query only
 	sort := "asc"
 	s := c.Query("sort")
@@ -58,14 +103,56 @@ func GetAllMovies(c *gin.Context) {
 		"movies":       movies,
 		"total_movies": totalMovies,
 		"next_offset":  nextOffset,
+		"languages":    languages,
 	})
 }
 
+// requestLanguages is the languages a movie listing is narrowed to: ?language (comma separated), or else
+// the user's preferred languages. language=all lists every language.
+func requestLanguages(c *gin.Context) []string {
+	if l := c.Query("language"); l != "" {
+		if strings.EqualFold(l, "all") {
+			return nil
+		}
+		return strings.Split(l, ",")
+	}
+	if user, ok := c.Get("user"); ok {
+		return user.(models.User).Preferences.Languages
+	}
+	return nil
+}
+
+// movieLanguageScope narrows a query on movies to those in any of the languages, ignoring case.
+// Movies without languages set are kept, rather than hidden from everyone with a preference.
+func movieLanguageScope(languages []string) func(*gorm.DB) *gorm.DB {
+	lower := make([]string, 0, len(languages))
+	for _, l := range languages {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			lower = append(lower, l)
+		}
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if len(lower) == 0 {
+			return db
+		}
+		list := "(CASE WHEN jsonb_typeof(COALESCE(NULLIF(movies.languages, ''), 'null')::jsonb) = 'array' THEN movies.languages::jsonb ELSE '[]'::jsonb END)"
+		return db.Where("jsonb_array_length("+list+") = 0 OR EXISTS (SELECT 1 FROM jsonb_array_elements_text("+list+") AS l WHERE lower(l) IN ?)", lower)
+	}
+}
+
 type MovieRequestBody struct {
 	Title       string `json:"title" validate:"required,min=2,max=50"`
 	Description string `json:"desc" validate:"required"`
 	Duration    string `json:"duration" validate:"required"`
 	Poster      string `json:"poster"`
+
+	Genres    []string `json:"genres"`
+	Languages []string `json:"languages"`
+	Cast      []string `json:"cast"`
+
+	PreBookingAt *time.Time `json:"pre_booking_at"`
+	ReleaseDate  *time.Time `json:"release_date"`
+	EndDate      *time.Time `json:"end_date"`
 }
 
 func CreateMovie(c *gin.Context) {
@@ -78,39 +165,163 @@ func CreateMovie(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
 		return
 	}
-	//We are checking we are authorized or not
-	user, _ := c.Get("user")
-	//We get userDetails, because we need to check that we are admin or not
-	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
-		return
-	}
 	movie := models.Movie{
 		Title:       body.Title,
 		Description: body.Description,
 		Duration:    body.Duration,
 		Poster:      body.Poster,
+		Genres:      body.Genres,
+		Languages:   body.Languages,
+		Cast:        body.Cast,
+
+		PreBookingAt: body.PreBookingAt,
+		ReleaseDate:  body.ReleaseDate,
+		EndDate:      body.EndDate,
 	}
 	result := initializers.Db.Create(&movie)
 	if result.Error != nil {
 		c.Status(http.StatusBadRequest)
 		return
 	}
+	movie.Status = movie.LifecycleStage(time.Now())
 	c.JSON(http.StatusCreated, gin.H{
 		"movie": movie,
 	})
 }
 
+func isMovieStage(stage string) bool {
+	for _, s := range models.MovieStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+type MovieLifecycleBody struct {
+	PreBookingAt   *time.Time `json:"pre_booking_at"`
+	ReleaseDate    *time.Time `json:"release_date"`
+	EndDate        *time.Time `json:"end_date"`
+	StatusOverride *string    `json:"status_override"` // Empty clears the override
+	// Dates to remove, e.g. ["pre_booking_at"]
+	Clear []string `json:"clear" validate:"dive,oneof=pre_booking_at release_date end_date"`
+}
+
+// UpdateMovieLifecycle lets an admin move the release window or pin a movie to a stage. Only the fields
+// sent change.
+func UpdateMovieLifecycle(c *gin.Context) {
+	var body MovieLifecycleBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if body.StatusOverride != nil && *body.StatusOverride != "" && !isMovieStage(*body.StatusOverride) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status override"})
+		return
+	}
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	for _, field := range body.Clear {
+		switch field {
+		case "pre_booking_at":
+			movie.PreBookingAt = nil
+		case "release_date":
+			movie.ReleaseDate = nil
+		case "end_date":
+			movie.EndDate = nil
+		}
+	}
+	if body.PreBookingAt != nil {
+		movie.PreBookingAt = body.PreBookingAt
+	}
+	if body.ReleaseDate != nil {
+		movie.ReleaseDate = body.ReleaseDate
+	}
+	if body.EndDate != nil {
+		movie.EndDate = body.EndDate
+	}
+	if body.StatusOverride != nil {
+		movie.StatusOverride = *body.StatusOverride
+	}
+	// Checked on the merged window, so moving one date can't put it on the wrong side of a stored one
+	if movie.ReleaseDate != nil && movie.EndDate != nil && movie.EndDate.Before(*movie.ReleaseDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after release date"})
+		return
+	}
+	if movie.ReleaseDate != nil && movie.PreBookingAt != nil && movie.PreBookingAt.After(*movie.ReleaseDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pre-booking must open before release date"})
+		return
+	}
+	if err := initializers.Db.Save(&movie).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie lifecycle"})
+		return
+	}
+	movie.Status = movie.LifecycleStage(time.Now())
+	// Booking may have just opened, don't make interested users wait for the next sweep
+	if movie.IsBookable(time.Now()) {
+		go helpers.NotifyBookingOpened()
+	}
+	c.JSON(http.StatusOK, gin.H{"movie": movie})
+}
+
+// RegisterMovieInterest asks to be notified when booking opens for a movie
+func RegisterMovieInterest(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if movie.IsBookable(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking is already open for this movie"})
+		return
+	}
+	if movie.Status == models.StageArchived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This movie is no longer showing"})
+		return
+	}
+	interest := models.MovieInterest{UserID: userDetails.ID, MovieID: movie.ID}
+	if err := initializers.Db.Where(&interest).FirstOrCreate(&interest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register interest"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "We will notify you when booking opens"})
+}
+
+func RemoveMovieInterest(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	// Hard delete so the user can register again later
+	if err := initializers.Db.Unscoped().Where("user_id = ? AND movie_id = ?", userDetails.ID, c.Param("id")).
+		Delete(&models.MovieInterest{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove interest"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Interest removed"})
+}
+
 func GetMovieByID(c *gin.Context) {
 	movieID := c.Param("id")
 	var movie models.Movie
-	if err := initializers.Db.Preload("Venues").First(&movie, movieID).Error; err != nil {
+	if err := initializers.Db.Preload("Venues").Preload("Credits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order")
+	}).Preload("Media", func(db *gorm.DB) *gorm.DB {
+		return db.Order("type, is_primary desc, sort_order")
+	}).First(&movie, movieID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"movie": movie,
+		"movie":  movie,
+		"rating": movieRating(movie.ID),
 	})
 }
 
@@ -119,12 +330,6 @@ type UpdateMovieBody struct {
 }
 
 func UpdateMoviePoster(c *gin.Context) {
-	user, _ := c.Get("user")
-	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
-		return
-	}
 	movieID := c.Param("id")
 	var body UpdateMovieBody
 	if err := c.BindJSON(&body); err != nil {
@@ -137,10 +342,21 @@ func UpdateMoviePoster(c *gin.Context) {
 		return
 	}
 	movie.Poster = body.Poster
-	if err := initializers.Db.Save(&movie).Error; err != nil {
+	// A linked poster has no processed sizes
+	movie.PosterVariants = nil
+	var replaced []string
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if replaced, err = retirePrimaryPosters(tx, movie.ID, nil); err != nil {
+			return err
+		}
+		return tx.Save(&movie).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update poster"})
 		return
 	}
+	helpers.DeleteFiles(c.Request.Context(), replaced)
 	c.JSON(http.StatusOK, gin.H{"movie": movie})
 }
 
@@ -150,11 +366,36 @@ type VenueWithShowTimes struct {
 	ShowTimes []models.ShowTime `json:"show_times"`
 }
 
+// GetVenuesByMovieID lists the venues in the request's city showing a movie with their showtimes.
+// Given lat and lng, only venues within the radius are listed, nearest first, with their distance.
 func GetVenuesByMovieID(c *gin.Context) {
 	movieID := c.Param("id")
+	city, status, err := requestCity(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	geo, err := parseGeoFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := initializers.Db.Preload("Venue").Preload("Movie").Scopes(showTimeCityScope(city)).Where("movie_id = ?", movieID)
+	distances := map[uint]float64{}
+	if geo != nil {
+		nearby, err := venuesWithin(*geo, city)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search venues"})
+			return
+		}
+		for _, v := range nearby {
+			distances[v.ID] = v.DistanceKm
+		}
+		query = query.Where("venue_id IN ?", venueIDs(nearby))
+	}
 	var showTimes []models.ShowTime
 	/// Retrieve the show times for the given movie ID, preloading the associated venue and movie
-	if err := initializers.Db.Preload("Venue").Preload("Movie").Where("movie_id = ?", movieID).Find(&showTimes).Error; err != nil {
+	if err := query.Find(&showTimes).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No showtimes found for this movie"})
 		return
 	}
@@ -178,6 +419,9 @@ func GetVenuesByMovieID(c *gin.Context) {
 				"movie_name": showTime.Movie.Title,
 				"show_times": []gin.H{showTimeObj},
 			}
+			if geo != nil {
+				venueMap[venueID]["distance_km"] = distances[venueID]
+			}
 		}
 	}
 	// Convert the map to a list for the response
@@ -185,19 +429,17 @@ func GetVenuesByMovieID(c *gin.Context) {
 	for _, venue := range venueMap {
 		venues = append(venues, venue)
 	}
+	if geo != nil {
+		sort.SliceStable(venues, func(i, j int) bool {
+			return venues[i]["distance_km"].(float64) < venues[j]["distance_km"].(float64)
+		})
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"venues": venues,
 	})
 }
 
 func UploadMoviePoster(c *gin.Context) {
-	user, _ := c.Get("user")
-	//We get userDetails, because we need to check that we are admin or not
-	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
-		return
-	}
 	form, err := c.MultipartForm()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -210,34 +452,85 @@ func UploadMoviePoster(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded under 'poster' key"})
 		return
 	}
+	// Gallery details, all optional - a bare upload replaces the primary poster as before
+	media, err := mediaFromForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if media.Type == models.MediaTrailer {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Trailers are added as links, use POST /movies/:id/media"})
+		return
+	}
+	movieID := c.Param("id")
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, "id = ?", movieID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
 	// Save the first file in the form (assuming single file upload)
 	fileHeader := files[0]
+	if fileHeader.Size > helpers.MaxImageBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": helpers.ErrImageTooLarge.Error()})
+		return
+	}
 	f, err := fileHeader.Open()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error opening file"})
 		return
 	}
 	defer f.Close()
-	uploadedURL, err := helpers.SaveFile(f, fileHeader)
+	data, err := io.ReadAll(io.LimitReader(f, helpers.MaxImageBytes+1))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Error saving file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file"})
 		return
 	}
-	// Retrieve the movie ID from the form (assuming it's passed as "movie_id")
-	movieID := c.Param("id")
-	var movie models.Movie
-	if err := initializers.Db.First(&movie, "id = ?", movieID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	// Re-encode to the standard sizes; the original bytes are never stored
+	processed, err := helpers.ProcessImage(data, helpers.VariantsFor(media.Type))
+	switch {
+	case errors.Is(err, helpers.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, helpers.ErrUnsupportedImage):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing image"})
 		return
 	}
 
-	// Update the Movie's Poster field with the uploaded URL
-	movie.Poster = uploadedURL
-	if err := initializers.Db.Save(&movie).Error; err != nil {
+	ctx := c.Request.Context()
+	uploadKey := fmt.Sprintf("movies/%d/%s/%d", movie.ID, media.Type, time.Now().UnixNano())
+	variants := make([]models.MovieMedia, 0, len(processed))
+	var stored []string
+	for _, p := range processed {
+		key := uploadKey + "/" + p.Variant + p.Extension()
+		url, err := helpers.SaveFile(ctx, key, bytes.NewReader(p.Data), p.ContentType)
+		if err != nil {
+			helpers.DeleteFiles(ctx, stored)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error saving file"})
+			return
+		}
+		stored = append(stored, key)
+		variant := media
+		variant.MovieID = movie.ID
+		variant.URL = url
+		variant.Size = p.Variant
+		variant.Format = p.Format
+		variant.Width = p.Width
+		variant.Height = p.Height
+		variant.UploadKey = uploadKey
+		variant.StorageKey = key
+		variants = append(variants, variant)
+	}
+	if err := saveMovieVariants(ctx, &movie, variants); err != nil {
+		helpers.DeleteFiles(ctx, stored)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save movie poster URL"})
 		return
 	}
+	url, _ := posterFromVariants(variants)
 	c.JSON(http.StatusOK, gin.H{
-		"url": uploadedURL,
+		"url":      url,
+		"variants": variants,
 	})
 }
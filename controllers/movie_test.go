@@ -55,3 +55,38 @@ func TestVenueRequestBody_Validation(t *testing.T) {
 		t.Error("invalid venue body should fail validation")
 	}
 }
+
+func TestReviewRequestBody_Validation(t *testing.T) {
+	// Ratings are on a 1-10 scale
+	for _, rating := range []int{1, 10} {
+		if err := validate.Struct(ReviewRequestBody{Rating: rating}); err != nil {
+			t.Errorf("rating %d should pass: %v", rating, err)
+		}
+	}
+	for _, rating := range []int{0, 11} {
+		if err := validate.Struct(ReviewRequestBody{Rating: rating}); err == nil {
+			t.Errorf("rating %d should fail validation", rating)
+		}
+	}
+}
+
+func TestParseShowTiming(t *testing.T) {
+	timing, startsAt, err := parseShowTiming("18:30")
+	if err != nil || timing != "18:30" || startsAt != nil {
+		t.Errorf("daily timing: got %q %v %v", timing, startsAt, err)
+	}
+
+	timing, startsAt, err = parseShowTiming("2026-11-20T21:15:00+05:30")
+	if err != nil || timing != "21:15" || startsAt == nil {
+		t.Fatalf("dated timing: got %q %v %v", timing, startsAt, err)
+	}
+	if startsAt.UTC().Format("2006-01-02 15:04") != "2026-11-20 15:45" {
+		t.Errorf("unexpected start %v", startsAt)
+	}
+
+	for _, invalid := range []string{"", "6pm", "25:00", "2026-11-20"} {
+		if _, _, err := parseShowTiming(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/Snehil208001/BookMyShowApp/oidc"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// oidcLoginTTL is how long the user has to sign in at the provider and come back
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcRedirectURL is the web app page the provider sends the user back to. It posts the code and state
+// to POST /user/oidc/:provider/callback, after checking the state is the one it started with.
+func oidcRedirectURL(provider string) string {
+	return helpers.FrontendLink("/oidc/callback/"+provider, nil)
+}
+
+func oidcProvider(c *gin.Context) (*oidc.Provider, bool) {
+	provider, ok := initializers.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown sign-in provider"})
+	}
+	return provider, ok
+}
+
+// startOIDC records a sign-in with the provider and returns where to send the user. A userID makes it a link
+// to that user instead of a login.
+func startOIDC(c *gin.Context, provider *oidc.Provider, userID *uint) {
+	state, stateHash := helpers.NewSecretToken()
+	login := models.OIDCLogin{
+		Provider:     provider.Name,
+		StateHash:    stateHash,
+		Nonce:        helpers.NewTokenID(),
+		CodeVerifier: oidc.NewCodeVerifier(),
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	}
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), oidcRedirectURL(provider.Name), state, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Sign-in provider is unavailable"})
+		return
+	}
+	if err := initializers.Db.Create(&login).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL, "state": state})
+}
+
+// GetOIDCProviders lists the providers users can sign in with
+func GetOIDCProviders(c *gin.Context) {
+	names := make([]string, 0, len(initializers.OIDC))
+	for name := range initializers.OIDC {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+// StartOIDCLogin begins signing in with a provider using the authorization code flow with PKCE
+func StartOIDCLogin(c *gin.Context) {
+	if provider, ok := oidcProvider(c); ok {
+		startOIDC(c, provider, nil)
+	}
+}
+
+// StartOIDCLink begins linking a provider to the logged in user, so they can sign in with it later
+func StartOIDCLink(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	if provider, ok := oidcProvider(c); ok {
+		startOIDC(c, provider, &userDetails.ID)
+	}
+}
+
+// OIDCCallback finishes a sign-in or link with the code and state the provider redirected back with.
+// A link must be finished while logged in as the user who started it. A login finds the user by the linked identity, then by verified email, and otherwise signs them up.
+func OIDCCallback(c *gin.Context) {
+	provider, ok := oidcProvider(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Code  string `json:"code" validate:"required"`
+		State string `json:"state" validate:"required"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var login models.OIDCLogin
+	err := initializers.Db.Where("state_hash = ? AND provider = ? AND used_at IS NULL AND expires_at > ?",
+		helpers.HashToken(body.State), provider.Name, time.Now()).First(&login).Error
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sign-in expired, please try again"})
+		return
+	}
+	// A link is finished by the user who started it, so nobody can attach their provider account to someone
+	// else's by getting them to follow the callback, or the other way round
+	if login.UserID != nil {
+		user, ok := c.Get("user")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Log in to link " + provider.Name})
+			return
+		}
+		if user.(models.User).ID != *login.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This link was started by another account"})
+			return
+		}
+	}
+	// Claiming the state first means a replayed callback can't finish the same sign-in twice
+	result := initializers.Db.Model(&models.OIDCLogin{}).Where("id = ? AND used_at IS NULL", login.ID).Update("used_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sign-in expired, please try again"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	claims, err := provider.Exchange(ctx, oidcRedirectURL(provider.Name), body.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in with " + provider.Name + " failed"})
+		return
+	}
+
+	var identity models.Identity
+	initializers.Db.Where("provider = ? AND subject = ?", provider.Name, claims.Subject).Limit(1).Find(&identity)
+
+	if login.UserID != nil {
+		linkIdentity(c, *login.UserID, identity, provider.Name, claims)
+		return
+	}
+
+	now := time.Now()
+	if identity.ID != 0 {
+		var user models.User
+		if err := initializers.Db.First(&user, identity.UserID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		initializers.Db.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "last_login_at": now})
+		completeLogin(c, user)
+		return
+	}
+
+	// Without a verified email there's no telling whose account this is
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your " + provider.Name + " account has no verified email"})
+		return
+	}
+	identity = models.Identity{Provider: provider.Name, Subject: claims.Subject, Email: claims.Email, LastLoginAt: &now}
+
+	var user models.User
+	initializers.Db.Where("email = ?", claims.Email).Limit(1).Find(&user)
+	if user.ID != 0 {
+		// Someone could have signed up with the address without owning it, so only verified accounts are linked
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email exists, log in with your password and link " + provider.Name + " from your account"})
+			return
+		}
+		identity.UserID = user.ID
+		if err := initializers.Db.Create(&identity).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+			return
+		}
+		completeLogin(c, user)
+		return
+	}
+
+	// A new account, with a password nobody knows until the user sets one with a reset link
+	password, _ := helpers.NewSecretToken()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash the password"})
+		return
+	}
+	user = models.User{
+		Name:            oidcDisplayName(claims),
+		Email:           claims.Email,
+		Password:        string(hash),
+		NoPassword:      true,
+		EmailVerifiedAt: &now,
+	}
+	err = initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(&identity).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+	completeLogin(c, user)
+}
+
+// linkIdentity links the provider account to the user who started the link
+func linkIdentity(c *gin.Context, userID uint, identity models.Identity, provider string, claims *oidc.Claims) {
+	if identity.ID != 0 {
+		if identity.UserID != userID {
+			c.JSON(http.StatusConflict, gin.H{"error": "This " + provider + " account is linked to another user"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Already linked", "identity": identity})
+		return
+	}
+	identity = models.Identity{UserID: userID, Provider: provider, Subject: claims.Subject, Email: claims.Email}
+	if err := initializers.Db.Create(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Linked " + provider, "identity": identity})
+}
+
+// oidcDisplayName is the name a new account gets, the provider's or else the start of the email
+func oidcDisplayName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if len([]rune(name)) < 2 {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if r := []rune(name); len(r) > 50 {
+		name = string(r[:50])
+	}
+	return name
+}
+
+// GetIdentities lists the providers linked to the user
+func GetIdentities(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var identities []models.Identity
+	initializers.Db.Where("user_id = ?", userDetails.ID).Order("created_at").Find(&identities)
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// UnlinkIdentity removes a linked provider. The last one stays while the user has no other way to sign in.
+func UnlinkIdentity(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+
+	var identity models.Identity
+	if err := initializers.Db.Where("user_id = ?", userDetails.ID).First(&identity, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Linked account not found"})
+		return
+	}
+	if userDetails.NoPassword && userDetails.PhoneVerifiedAt == nil {
+		var count int64
+		initializers.Db.Model(&models.Identity{}).Where("user_id = ?", userDetails.ID).Count(&count)
+		if count <= 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password before unlinking your only way to sign in"})
+			return
+		}
+	}
+	// Deleted for good so the provider account can be linked again
+	if err := initializers.Db.Unscoped().Delete(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unlinked " + identity.Provider})
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+func GetOperators(c *gin.Context) {
+	var operators []models.Operator
+	initializers.Db.Order("name").Find(&operators)
+	c.JSON(http.StatusOK, gin.H{"operators": operators})
+}
+
+// GetOperatorByID returns the operator and its venues. Its own admins also see the payout settings,
+// with the account number masked.
+func GetOperatorByID(c *gin.Context) {
+	var operator models.Operator
+	if err := initializers.Db.First(&operator, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+		return
+	}
+	var venues []models.Venue
+	initializers.Db.Where("operator_id = ?", operator.ID).Order("name").Find(&venues)
+	response := gin.H{"operator": operator, "venues": venues}
+	if user, ok := c.Get("user"); ok && user.(models.User).CanForOperator(models.PermManageOperator, operator.ID) {
+		response["payout_settings"] = operator.PayoutSettings.Masked()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+type OperatorRequestBody struct {
+	Name            string                     `json:"name" validate:"required"`
+	Branding        models.Branding            `json:"branding"`
+	PricingDefaults models.PricingDefaults     `json:"pricing_defaults"`
+	RefundPolicy    *models.CancellationPolicy `json:"refund_policy"`
+	PayoutSettings  models.PayoutSettings      `json:"payout_settings"`
+}
+
+func operatorNameTaken(name string, excludeID uint) bool {
+	var count int64
+	initializers.Db.Model(&models.Operator{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).Count(&count)
+	return count > 0
+}
+
+func CreateOperator(c *gin.Context) {
+	var body OperatorRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if operatorNameTaken(body.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Operator name already in use"})
+		return
+	}
+	operator := models.Operator{
+		Name:            body.Name,
+		Branding:        body.Branding,
+		PricingDefaults: body.PricingDefaults,
+		RefundPolicy:    body.RefundPolicy,
+		PayoutSettings:  body.PayoutSettings,
+	}
+	if err := initializers.Db.Create(&operator).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create operator"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"operator": operator, "payout_settings": operator.PayoutSettings.Masked()})
+}
+
+type UpdateOperatorBody struct {
+	Name            *string                    `json:"name" validate:"omitempty,min=1"`
+	Branding        *models.Branding           `json:"branding"`
+	PricingDefaults *models.PricingDefaults    `json:"pricing_defaults"`
+	RefundPolicy    *models.CancellationPolicy `json:"refund_policy"`
+	ClearPolicy     bool                       `json:"clear_refund_policy"`
+	PayoutSettings  *models.PayoutSettings     `json:"payout_settings"`
+}
+
+// UpdateOperator lets a super admin or the operator's own admins change its branding, defaults and payouts.
+// Only a super admin can rename it.
+func UpdateOperator(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body UpdateOperatorBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var operator models.Operator
+	if err := initializers.Db.First(&operator, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+		return
+	}
+	if !userDetails.CanForOperator(models.PermManageOperator, operator.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own operator"})
+		return
+	}
+	if body.Name != nil {
+		if !userDetails.CanGlobally(models.PermManageOperators) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only a super admin can rename an operator"})
+			return
+		}
+		if operatorNameTaken(*body.Name, operator.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Operator name already in use"})
+			return
+		}
+		operator.Name = *body.Name
+	}
+	if body.Branding != nil {
+		operator.Branding = *body.Branding
+	}
+	if body.PricingDefaults != nil {
+		operator.PricingDefaults = *body.PricingDefaults
+	}
+	if body.RefundPolicy != nil {
+		operator.RefundPolicy = body.RefundPolicy
+	} else if body.ClearPolicy {
+		operator.RefundPolicy = nil
+	}
+	if body.PayoutSettings != nil {
+		operator.PayoutSettings = *body.PayoutSettings
+	}
+	if err := initializers.Db.Save(&operator).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update operator"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"operator": operator, "payout_settings": operator.PayoutSettings.Masked()})
+}
+
+func GetOperatorAdmins(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var operator models.Operator
+	if err := initializers.Db.First(&operator, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+		return
+	}
+	if !userDetails.CanForOperator(models.PermManageOperator, operator.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own operator"})
+		return
+	}
+	var admins []models.User
+	initializers.Db.Where("id IN (?)", initializers.Db.Model(&models.RoleAssignment{}).Select("user_id").
+		Where("role = ? AND operator_id = ?", models.RoleOperatorAdmin, operator.ID)).Order("name").Find(&admins)
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+}
+
+// AddOperatorAdmin grants a user the operator admin role over the operator's venues
+func AddOperatorAdmin(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var operator models.Operator
+	if err := initializers.Db.First(&operator, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+		return
+	}
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("userId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	assignment := models.RoleAssignment{UserID: target.ID, Role: models.RoleOperatorAdmin, OperatorID: &operator.ID}
+	if status, err := grantRole(c, userDetails, &target, assignment); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": target})
+}
+
+// RemoveOperatorAdmin takes the operator admin role over the operator away from a user
+func RemoveOperatorAdmin(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var assignment models.RoleAssignment
+	if err := initializers.Db.Where("role = ? AND operator_id = ? AND user_id = ?", models.RoleOperatorAdmin, c.Param("id"), c.Param("userId")).
+		First(&assignment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not an admin of this operator"})
+		return
+	}
+	if status, err := revokeRole(c, userDetails, assignment); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Operator admin removed"})
+}
+
+// VenueSales is one venue's line in an operator report
+type VenueSales struct {
+	VenueID   uint    `json:"venue_id"`
+	VenueName string  `json:"venue_name"`
+	Orders    int64   `json:"orders"`
+	Tickets   int64   `json:"tickets"`
+	Gross     float64 `json:"gross"`
+	Refunded  float64 `json:"refunded"`
+	Net       float64 `json:"net"`
+}
+
+// GetOperatorReport totals ticket sales per venue for orders placed between ?from and ?to (YYYY-MM-DD,
+// both inclusive, the last 30 days by default), along with the commission and what's owed to the operator.
+func GetOperatorReport(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var operator models.Operator
+	if err := initializers.Db.First(&operator, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+		return
+	}
+	if !userDetails.CanForOperator(models.PermManageOperator, operator.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only see your own operator's reports"})
+		return
+	}
+	today := time.Now().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -30), today
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	// Venues and showtimes deleted since still count towards the sales made through them
+	var sales []VenueSales
+	initializers.Db.Raw(`
+		SELECT venues.id AS venue_id, venues.name AS venue_name,
+			COUNT(DISTINCT orders.id) AS orders, COUNT(order_seats.seat_id) AS tickets
+		FROM orders
+		JOIN show_times ON show_times.id = orders.show_time_id
+		JOIN venues ON venues.id = show_times.venue_id
+		LEFT JOIN order_seats ON order_seats.order_id = orders.id
+		WHERE venues.operator_id = ? AND orders.deleted_at IS NULL
+			AND orders.created_at >= ? AND orders.created_at < ?
+		GROUP BY venues.id, venues.name
+		ORDER BY venues.name`, operator.ID, from, to.AddDate(0, 0, 1)).Scan(&sales)
+	// Money is summed separately, the seat join would count each order once per seat
+	type venueMoney struct {
+		VenueID  uint
+		Gross    float64
+		Refunded float64
+	}
+	var money []venueMoney
+	initializers.Db.Raw(`
+		SELECT show_times.venue_id, SUM(orders.total_price) AS gross, SUM(orders.refund_amount) AS refunded
+		FROM orders
+		JOIN show_times ON show_times.id = orders.show_time_id
+		JOIN venues ON venues.id = show_times.venue_id
+		WHERE venues.operator_id = ? AND orders.deleted_at IS NULL
+			AND orders.created_at >= ? AND orders.created_at < ?
+		GROUP BY show_times.venue_id`, operator.ID, from, to.AddDate(0, 0, 1)).Scan(&money)
+	byVenue := map[uint]venueMoney{}
+	for _, m := range money {
+		byVenue[m.VenueID] = m
+	}
+
+	var total VenueSales
+	for i := range sales {
+		m := byVenue[sales[i].VenueID]
+		sales[i].Gross, sales[i].Refunded = m.Gross, m.Refunded
+		sales[i].Net = m.Gross - m.Refunded
+		total.Orders += sales[i].Orders
+		total.Tickets += sales[i].Tickets
+		total.Gross += sales[i].Gross
+		total.Refunded += sales[i].Refunded
+		total.Net += sales[i].Net
+	}
+	commission := total.Net * operator.PayoutSettings.CommissionPercent / 100
+	c.JSON(http.StatusOK, gin.H{
+		"operator":   operator.Name,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"venues":     sales,
+		"orders":     total.Orders,
+		"tickets":    total.Tickets,
+		"gross":      total.Gross,
+		"refunded":   total.Refunded,
+		"net":        total.Net,
+		"commission": commission,
+		"payout":     total.Net - commission,
+	})
+}
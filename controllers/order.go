@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
 )
 
 type OrderResponse struct {
@@ -15,6 +17,11 @@ type OrderResponse struct {
 	MovieName  string   `json:"movie_name"`
 	VenueName  string   `json:"venue_name"`
 	Showtime   string   `json:"showtime"`
+
+	Status       string     `json:"status"`
+	RefundAmount float32    `json:"refund_amount,omitempty"`
+	RefundedAt   *time.Time `json:"refunded_at,omitempty"`
+	RefundReason string     `json:"refund_reason,omitempty"`
 }
 
 func GetOrders(c *gin.Context) {
@@ -30,6 +37,13 @@ func GetOrders(c *gin.Context) {
 		return
 	}
 
+	c.JSON(200, gin.H{
+		"orders": buildOrderResponses(orders),
+	})
+}
+
+// buildOrderResponses looks up what each order was for, as the user's order history shows it
+func buildOrderResponses(orders []models.Order) []OrderResponse {
 	var orderResponses []OrderResponse
 	for _, order := range orders {
 		var seatNumbers []string
@@ -38,7 +52,9 @@ func GetOrders(c *gin.Context) {
 		}
 		movieName, venueName, showtime := "", "", ""
 		var st models.ShowTime
-		if err := initializers.Db.Preload("Venue").Preload("Movie").First(&st, order.ShowTimeID).Error; err == nil {
+		// Cancelled showtimes are soft deleted but still belong in the order history
+		if err := initializers.Db.Unscoped().Preload("Venue", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Preload("Movie").First(&st, order.ShowTimeID).Error; err == nil {
 			showtime = st.Timing
 			if st.Venue.ID != 0 {
 				venueName = st.Venue.Name + " - " + st.Venue.Location
@@ -54,10 +70,89 @@ func GetOrders(c *gin.Context) {
 			MovieName:  movieName,
 			VenueName:  venueName,
 			Showtime:   showtime,
+
+			Status:       order.Status,
+			RefundAmount: order.RefundAmount,
+			RefundedAt:   order.RefundedAt,
+			RefundReason: order.RefundReason,
 		})
 	}
+	return orderResponses
+}
 
-	c.JSON(200, gin.H{
-		"orders": orderResponses,
-	})
+// VenueOrderResponse is an order as box office staff see it, with who to hand the tickets to
+type VenueOrderResponse struct {
+	ID            uint      `json:"id"`
+	ShowTimeID    uint      `json:"showtime_id"`
+	Showtime      string    `json:"showtime"`
+	MovieName     string    `json:"movie_name"`
+	Seats         []string  `json:"seats"`
+	TotalPrice    float32   `json:"total_price"`
+	Status        string    `json:"status"`
+	CustomerName  string    `json:"customer_name"`
+	CustomerEmail string    `json:"customer_email"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GetVenueOrders lists the latest orders for a venue's shows, narrowed with ?showtime_id or ?order_id,
+// for box office staff checking bookings at the counter
+func GetVenueOrders(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermViewSales, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't see this venue's orders"})
+		return
+	}
+
+	query := initializers.Db.Joins("JOIN show_times ON show_times.id = orders.show_time_id").
+		Where("show_times.venue_id = ?", venue.ID)
+	if v := c.Query("showtime_id"); v != "" {
+		query = query.Where("orders.show_time_id = ?", v)
+	}
+	if v := c.Query("order_id"); v != "" {
+		query = query.Where("orders.id = ?", v)
+	}
+	var orders []models.Order
+	if err := query.Preload("Seats").Order("orders.created_at DESC").Limit(200).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load orders"})
+		return
+	}
+
+	showTimes := map[uint]models.ShowTime{}
+	customers := map[uint]models.User{}
+	responses := make([]VenueOrderResponse, 0, len(orders))
+	for _, order := range orders {
+		st, ok := showTimes[order.ShowTimeID]
+		if !ok {
+			initializers.Db.Unscoped().Preload("Movie").First(&st, order.ShowTimeID)
+			showTimes[order.ShowTimeID] = st
+		}
+		customer, ok := customers[order.UserID]
+		if !ok {
+			initializers.Db.Unscoped().First(&customer, order.UserID)
+			customers[order.UserID] = customer
+		}
+		var seatNumbers []string
+		for _, seat := range order.Seats {
+			seatNumbers = append(seatNumbers, seat.SeatNumber)
+		}
+		responses = append(responses, VenueOrderResponse{
+			ID:            order.ID,
+			ShowTimeID:    order.ShowTimeID,
+			Showtime:      st.Timing,
+			MovieName:     st.Movie.Title,
+			Seats:         seatNumbers,
+			TotalPrice:    order.TotalPrice,
+			Status:        order.Status,
+			CustomerName:  customer.Name,
+			CustomerEmail: customer.Email,
+			CreatedAt:     order.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"venue_id": venue.ID, "orders": responses})
 }
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/Snehil208001/BookMyShowApp/otp"
+	"gorm.io/gorm"
+)
+
+// normalizePhone puts a number in E.164, adding OTP_DEFAULT_COUNTRY_CODE to numbers written without one
+func normalizePhone(raw string) (string, error) {
+	return otp.NormalizePhone(raw, os.Getenv("OTP_DEFAULT_COUNTRY_CODE"))
+}
+
+// sendOTP sends a code to the phone unless the resend cooldown or hourly limit holds it back,
+// in which case it returns how long to wait
+func sendOTP(ctx context.Context, phone, purpose string, userID uint) (time.Duration, error) {
+	now := time.Now()
+	var recent []models.OTPChallenge
+	initializers.Db.Where("phone = ? AND created_at > ?", phone, now.Add(-time.Hour)).Order("created_at").Find(&recent)
+	sent := make([]time.Time, len(recent))
+	for i, r := range recent {
+		sent[i] = r.CreatedAt
+	}
+	if wait := otp.DefaultPolicy.SendWait(sent, now); wait > 0 {
+		return wait, nil
+	}
+	if err := initializers.OTP.Send(ctx, phone, purpose); err != nil {
+		return 0, err
+	}
+	// Only the newest code counts
+	initializers.Db.Model(&models.OTPChallenge{}).
+		Where("phone = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", phone, purpose, now).
+		Update("expires_at", now)
+	challenge := models.OTPChallenge{Phone: phone, Purpose: purpose, UserID: userID, ExpiresAt: now.Add(otp.DefaultPolicy.TTL)}
+	return 0, initializers.Db.Create(&challenge).Error
+}
+
+// checkOTP checks a code against the phone's latest challenge, counting the attempt.
+// The returned status goes with the error.
+func checkOTP(ctx context.Context, phone, purpose, code string) (models.OTPChallenge, int, error) {
+	var challenge models.OTPChallenge
+	err := initializers.Db.Where("phone = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", phone, purpose, time.Now()).
+		Order("created_at DESC").First(&challenge).Error
+	if err != nil {
+		return challenge, http.StatusBadRequest, errors.New("Code expired, request a new one")
+	}
+	// Counting the attempt before checking means parallel guesses can't get past the limit
+	result := initializers.Db.Model(&models.OTPChallenge{}).
+		Where("id = ? AND attempts < ?", challenge.ID, otp.DefaultPolicy.MaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return challenge, http.StatusInternalServerError, errors.New("Failed to check code")
+	}
+	if result.RowsAffected == 0 {
+		return challenge, http.StatusTooManyRequests, errors.New("Too many attempts, request a new code")
+	}
+	if err := initializers.OTP.Check(ctx, phone, purpose, code); err != nil {
+		if errors.Is(err, otp.ErrInvalidCode) {
+			return challenge, http.StatusBadRequest, errors.New("Invalid code")
+		}
+		return challenge, http.StatusBadGateway, errors.New("Failed to check code")
+	}
+	now := time.Now()
+	initializers.Db.Model(&challenge).Update("consumed_at", now)
+	challenge.ConsumedAt = &now
+	return challenge, 0, nil
+}
+
+// phoneTaken reports whether another user has already verified the number
+func phoneTaken(phone string, userID uint) bool {
+	var count int64
+	initializers.Db.Model(&models.User{}).
+		Where("phone_number = ? AND phone_verified_at IS NOT NULL AND id <> ?", phone, userID).Count(&count)
+	return count > 0
+}
+
+type PhoneBody struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+type PhoneCodeBody struct {
+	Phone string `json:"phone" validate:"required"`
+	Code  string `json:"code" validate:"required,numeric,min=4,max=10"`
+}
+
+// SendPhoneVerification texts a code to confirm a phone number for the logged in user
+func SendPhoneVerification(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body PhoneBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	phone, err := normalizePhone(body.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if phoneTaken(phone, userDetails.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number is already in use"})
+		return
+	}
+	wait, err := sendOTP(c.Request.Context(), phone, models.OTPPurposeVerifyPhone, userDetails.ID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send code"})
+		return
+	}
+	if wait > 0 {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another code", "retry_after": int(wait.Seconds()) + 1})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Code sent", "phone": phone})
+}
+
+// VerifyPhone saves the phone number on the user once they enter the code sent to it
+func VerifyPhone(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body PhoneCodeBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	phone, err := normalizePhone(body.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	challenge, status, err := checkOTP(c.Request.Context(), phone, models.OTPPurposeVerifyPhone, body.Code)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if challenge.UserID != userDetails.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code expired, request a new one"})
+		return
+	}
+	if phoneTaken(phone, userDetails.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Phone number is already in use"})
+		return
+	}
+	now := time.Now()
+	if err := initializers.Db.Model(&userDetails).Updates(map[string]interface{}{"phone_number": phone, "phone_verified_at": now}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Phone verified", "user": userDetails})
+}
+
+// SendLoginOTP texts a login code to a verified phone number. The response is the same whether or not
+// the number has an account, and when the cooldown holds a code back, so it reveals nothing about users.
+func SendLoginOTP(c *gin.Context) {
+	var body PhoneBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	phone, err := normalizePhone(body.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var user models.User
+	if initializers.Db.Where("phone_number = ? AND phone_verified_at IS NOT NULL", phone).First(&user).Error == nil {
+		if _, err := sendOTP(c.Request.Context(), phone, models.OTPPurposeLogin, user.ID); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send code"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If the number belongs to an account, a code has been sent", "phone": phone})
+}
+
+// LoginWithOTP logs in with a code from SendLoginOTP, the same way a password login does
+func LoginWithOTP(c *gin.Context) {
+	var body PhoneCodeBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	phone, err := normalizePhone(body.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	challenge, status, err := checkOTP(c.Request.Context(), phone, models.OTPPurposeLogin, body.Code)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	var user models.User
+	if err := initializers.Db.Where("phone_number = ? AND phone_verified_at IS NOT NULL", phone).First(&user, challenge.UserID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code expired, request a new one"})
+		return
+	}
+	completeLogin(c, user)
+}
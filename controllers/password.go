@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	// A user asking again within this window gets no new email, so the endpoint can't be used to flood an inbox
+	passwordResetThrottle = time.Minute
+)
+
+// ForgotPassword emails a reset link when the address belongs to a user. The response is the same
+// either way so it can't be used to find out who has an account.
+func ForgotPassword(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	response := gin.H{"message": "If an account exists for that email, a reset link has been sent"}
+
+	var user models.User
+	if err := initializers.Db.Where("email = ?", body.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	var recent int64
+	initializers.Db.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-passwordResetThrottle)).Count(&recent)
+	if recent > 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if err := sendPasswordReset(user, "Use this link within an hour to choose a new password: %s\nIf you didn't ask for this, you can ignore this email."); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start password reset"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// sendPasswordReset replaces the user's outstanding reset links with a new one and emails it,
+// in the message's %s
+func sendPasswordReset(user models.User, message string) error {
+	token, hash := helpers.NewSecretToken()
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		// Only the newest link works
+		if err := tx.Model(&models.PasswordReset{}).Where("user_id = ? AND used_at IS NULL", user.ID).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordReset{UserID: user.ID, TokenHash: hash, ExpiresAt: time.Now().Add(passwordResetTTL)}).Error
+	})
+	if err != nil {
+		return err
+	}
+	link := helpers.FrontendLink("/reset-password", url.Values{"token": {token}})
+	helpers.NotifySecret(user, "Reset your password", fmt.Sprintf(message, link), token)
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and signs the user out everywhere
+func ResetPassword(c *gin.Context) {
+	var body struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=8"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash the password"})
+		return
+	}
+
+	var reset models.PasswordReset
+	err = initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", helpers.HashToken(body.Token), time.Now()).First(&reset).Error; err != nil {
+			return err
+		}
+		// Claiming the token first means two requests racing with it can't both succeed
+		result := tx.Model(&models.PasswordReset{}).Where("id = ? AND used_at IS NULL", reset.ID).Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.User{}).Where("id = ?", reset.UserID).
+			Updates(map[string]interface{}{"password": string(hash), "no_password": false}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	revokeUserTokens(reset.UserID)
+	var user models.User
+	if initializers.Db.First(&user, reset.UserID).Error == nil {
+		helpers.Notify(user, "Your password was reset", "Your password was just reset and you have been signed out on every device.")
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset, please log in again"})
+}
+
+// ChangePassword sets a new password for a logged in user who knows the current one.
+// Every other session is signed out, the one making the request stays logged in.
+func ChangePassword(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+
+	var body struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required,min=8,nefield=CurrentPassword"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if userDetails.NoPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your account has no password yet, set one with a reset link"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(body.CurrentPassword)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.NewPassword), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash the password"})
+		return
+	}
+	if err := initializers.Db.Model(&userDetails).Update("password", string(hash)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	revokeTokens(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? AND family_id <> ?", userDetails.ID, claims.SessionID)
+	})
+	// Outstanding reset links were for the old password
+	initializers.Db.Model(&models.PasswordReset{}).Where("user_id = ? AND used_at IS NULL", userDetails.ID).Update("used_at", time.Now())
+	helpers.Notify(userDetails, "Your password was changed", "Your password was just changed and your other devices have been signed out.")
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
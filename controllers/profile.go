@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// PreferencesBody changes the preferences that are set, leaving the rest as they are
+type PreferencesBody struct {
+	CityID               *uint     `json:"city_id"` // Cleared with PUT /user/me/city
+	Languages            *[]string `json:"languages"`
+	Genres               *[]string `json:"genres"`
+	SeatCategory         *string   `json:"seat_category"`
+	NotificationChannels *[]string `json:"notification_channels"`
+}
+
+// UpdateProfileBody changes the profile fields that are set
+type UpdateProfileBody struct {
+	Name        *string          `json:"name"`
+	Email       *string          `json:"email"`
+	PhoneNumber *string          `json:"phone_number"`
+	Preferences *PreferencesBody `json:"preferences"`
+}
+
+// UpdateMe changes the user's name and preferences straight away. A new email or phone number needs
+// verifying first: the email gets a link and the phone a code for POST /user/phone/verify, and the
+// account keeps the old one until then.
+func UpdateMe(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body UpdateProfileBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	updated := userDetails
+	var columns []string
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if n := len([]rune(name)); n < 2 || n > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be between 2 and 50 characters"})
+			return
+		}
+		updated.Name = name
+		columns = append(columns, "name")
+	}
+
+	if prefs := body.Preferences; prefs != nil {
+		if prefs.CityID != nil {
+			if _, status, err := preferableCity(*prefs.CityID); err != nil {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			updated.PreferredCityID = prefs.CityID
+			columns = append(columns, "preferred_city_id")
+		}
+		p := userDetails.Preferences
+		if prefs.Languages != nil {
+			p.Languages = *prefs.Languages
+		}
+		if prefs.Genres != nil {
+			p.Genres = *prefs.Genres
+		}
+		if prefs.SeatCategory != nil {
+			p.SeatCategory = *prefs.SeatCategory
+		}
+		if prefs.NotificationChannels != nil {
+			p.NotificationChannels = *prefs.NotificationChannels
+		}
+		if err := p.Normalize(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated.Preferences = p
+		columns = append(columns, "preferences")
+	}
+
+	// Asking for the current address back calls off a pending change
+	newEmail := ""
+	if body.Email != nil {
+		email := strings.TrimSpace(*body.Email)
+		if err := validate.Var(email, "required,email"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+		if email != userDetails.Email && email != userDetails.PendingEmail {
+			var taken int64
+			initializers.Db.Model(&models.User{}).Where("email = ?", email).Count(&taken)
+			if taken > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+				return
+			}
+			// Switching between addresses mustn't get round the resend limit
+			if wait := verificationWait(userDetails); wait > 0 {
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":       "A verification email was sent recently, try again shortly",
+					"retry_after": int(wait.Seconds()) + 1,
+				})
+				return
+			}
+			newEmail = email
+		}
+		if email == userDetails.Email {
+			updated.PendingEmail = ""
+		} else {
+			updated.PendingEmail = email
+		}
+		columns = append(columns, "pending_email")
+	}
+
+	newPhone := ""
+	if body.PhoneNumber != nil {
+		phone, err := normalizePhone(*body.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if phone != userDetails.PhoneNumber || userDetails.PhoneVerifiedAt == nil {
+			if phoneTaken(phone, userDetails.ID) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Phone number is already in use"})
+				return
+			}
+			newPhone = phone
+		}
+	}
+
+	if len(columns) > 0 {
+		if err := initializers.Db.Model(&userDetails).Select(columns).Updates(updated).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+	}
+	response := gin.H{"message": "Profile updated", "user": updated}
+
+	if newEmail != "" {
+		if err := sendEmailChangeVerification(&updated); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Profile updated but failed to send the verification email"})
+			return
+		}
+		response["email_verification_sent_to"] = newEmail
+	}
+	if newPhone != "" {
+		wait, err := sendOTP(c.Request.Context(), newPhone, models.OTPPurposeVerifyPhone, userDetails.ID)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Profile updated but failed to send a code to the new phone"})
+			return
+		}
+		if wait > 0 {
+			response["phone_verification_retry_after"] = int(wait.Seconds()) + 1
+		} else {
+			response["phone_verification_sent_to"] = newPhone
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+)
+
+// How far back bookings count towards a movie's popularity
+const popularityWindow = 30 * 24 * time.Hour
+
+type movieCount struct {
+	MovieID uint
+	Count   float64
+}
+
+// GetRecommendations ranks now showing movies for the logged in user from their booking history
+func GetRecommendations(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	limit := 10
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+	now := time.Now()
+
+	// Movies and venues from the user's past orders
+	var history []struct {
+		MovieID uint
+		VenueID uint
+	}
+	initializers.Db.Table("orders").
+		Select("show_times.movie_id, show_times.venue_id").
+		Joins("JOIN show_times ON show_times.id = orders.show_time_id").
+		Where("orders.user_id = ? AND orders.deleted_at IS NULL", userDetails.ID).
+		Scan(&history)
+
+	watchedIDs := []uint{}
+	watchedSet := map[uint]bool{}
+	var venueIDs []uint
+	for _, h := range history {
+		if !watchedSet[h.MovieID] {
+			watchedSet[h.MovieID] = true
+			watchedIDs = append(watchedIDs, h.MovieID)
+		}
+		venueIDs = append(venueIDs, h.VenueID)
+	}
+	var watched []models.Movie
+	if len(watchedIDs) > 0 {
+		initializers.Db.Where("id IN ?", watchedIDs).Find(&watched)
+	}
+	profile := helpers.BuildTasteProfile(watched, venueIDs)
+	profile.AddPreferences(userDetails.Preferences)
+
+	// Everything now showing in the user's city that they have not booked yet
+	city, status, err := requestCity(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	var movies []models.Movie
+	query := initializers.Db.Scopes(models.MovieStageScope(now, models.StageNowShowing), movieCityScope(city))
+	if len(watchedIDs) > 0 {
+		query = query.Where("movies.id NOT IN ?", watchedIDs)
+	}
+	if err := query.Find(&movies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load movies"})
+		return
+	}
+	if len(movies) == 0 {
+		c.JSON(http.StatusOK, gin.H{"recommendations": []helpers.Recommendation{}})
+		return
+	}
+	candidateIDs := make([]uint, len(movies))
+	for i, m := range movies {
+		candidateIDs[i] = m.ID
+	}
+
+	// Venues in the city playing each candidate
+	var playing []struct {
+		MovieID uint
+		VenueID uint
+	}
+	initializers.Db.Model(&models.ShowTime{}).Scopes(showTimeCityScope(city)).
+		Distinct("movie_id", "venue_id").
+		Where("movie_id IN ?", candidateIDs).
+		Scan(&playing)
+	venuesByMovie := map[uint][]uint{}
+	for _, p := range playing {
+		venuesByMovie[p.MovieID] = append(venuesByMovie[p.MovieID], p.VenueID)
+	}
+
+	// Co-booking: how many users who share a movie with this user also booked the candidate
+	coBookings := map[uint]float64{}
+	if len(watchedIDs) > 0 {
+		var counts []movieCount
+		initializers.Db.Raw(`
+			SELECT st.movie_id, COUNT(DISTINCT o.user_id) AS count
+			FROM orders o
+			JOIN show_times st ON st.id = o.show_time_id
+			WHERE o.deleted_at IS NULL AND st.movie_id IN @candidates AND o.user_id IN (
+				SELECT DISTINCT o1.user_id
+				FROM orders o1
+				JOIN show_times s1 ON s1.id = o1.show_time_id
+				WHERE o1.deleted_at IS NULL AND s1.movie_id IN @watched AND o1.user_id <> @user
+			)
+			GROUP BY st.movie_id`,
+			map[string]interface{}{"candidates": candidateIDs, "watched": watchedIDs, "user": userDetails.ID}).
+			Scan(&counts)
+		for _, cnt := range counts {
+			coBookings[cnt.MovieID] = cnt.Count
+		}
+	}
+
+	// Popularity: recent orders per movie across everyone
+	popularity := map[uint]float64{}
+	var counts []movieCount
+	initializers.Db.Table("orders").
+		Select("show_times.movie_id, COUNT(*) AS count").
+		Joins("JOIN show_times ON show_times.id = orders.show_time_id").
+		Where("orders.deleted_at IS NULL AND orders.created_at > ? AND show_times.movie_id IN ?", now.Add(-popularityWindow), candidateIDs).
+		Group("show_times.movie_id").
+		Scan(&counts)
+	for _, cnt := range counts {
+		popularity[cnt.MovieID] = cnt.Count
+	}
+
+	candidates := make([]helpers.RecommendationCandidate, len(movies))
+	for i, m := range movies {
+		candidates[i] = helpers.RecommendationCandidate{
+			Movie:      m,
+			VenueIDs:   venuesByMovie[m.ID],
+			CoBookings: coBookings[m.ID],
+			Popularity: popularity[m.ID],
+		}
+	}
+	recommendations := helpers.RankMovies(profile, candidates)
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"recommendations": recommendations,
+		"personalized":    !profile.IsEmpty(),
+	})
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRequestBody struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=10"`
+	Body   string `json:"body" validate:"max=2000"`
+}
+
+type ReviewResponse struct {
+	models.Review
+	UserName string `json:"user_name"`
+}
+
+type MovieRating struct {
+	Average       float64 `json:"average"`
+	Count         int64   `json:"count"`
+	VerifiedCount int64   `json:"verified_count"`
+}
+
+// movieRating aggregates the published reviews of a movie
+func movieRating(movieID uint) MovieRating {
+	var rating MovieRating
+	initializers.Db.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count, COUNT(*) FILTER (WHERE is_verified) AS verified_count").
+		Where("movie_id = ? AND status = ?", movieID, models.ReviewPublished).
+		Scan(&rating)
+	return rating
+}
+
+// hasWatchedMovie reports whether the user has an order, not refunded, for a showtime of the movie that has
+// played since they booked it. Tickets for a show still ahead don't count.
+func hasWatchedMovie(userID, movieID uint) bool {
+	now := time.Now()
+	var orders []models.Order
+	initializers.Db.Joins("JOIN show_times ON show_times.id = orders.show_time_id").
+		Where("orders.user_id = ? AND show_times.movie_id = ? AND orders.status <> ?", userID, movieID, models.OrderRefunded).
+		Where("(show_times.starts_at IS NULL OR show_times.starts_at <= ?)", now).
+		Find(&orders)
+	for _, order := range orders {
+		var showTime models.ShowTime
+		if err := initializers.Db.Unscoped().Preload("Venue.City.Region").First(&showTime, order.ShowTimeID).Error; err != nil {
+			continue
+		}
+		if showTime.HasPlayedSince(order.CreatedAt, now, showTime.Venue.TimeLocation()) {
+			return true
+		}
+	}
+	return false
+}
+
+func GetMovieReviews(c *gin.Context) {
+	limit := 10
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+	order := "reviews.created_at desc"
+	if c.Query("sort") == "helpful" {
+		order = "reviews.helpful_count desc, reviews.created_at desc"
+	}
+	query := initializers.Db.Model(&models.Review{}).
+		Where("reviews.movie_id = ? AND reviews.status = ?", c.Param("id"), models.ReviewPublished)
+	if c.Query("verified") == "true" {
+		query = query.Where("reviews.is_verified")
+	}
+	var total int64
+	query.Count(&total)
+
+	var reviews []ReviewResponse
+	query.Select("reviews.*, users.name AS user_name").
+		Joins("JOIN users ON users.id = reviews.user_id").
+		Order(order).Limit(limit).Offset(offset).
+		Scan(&reviews)
+
+	nextOffset := offset + limit
+	if nextOffset >= int(total) {
+		nextOffset = -1
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":       reviews,
+		"total_reviews": total,
+		"next_offset":   nextOffset,
+	})
+}
+
+func CreateReview(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body ReviewRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	// One review per user per movie - edits go through UpdateReview
+	var existing models.Review
+	if err := initializers.Db.Where("user_id = ? AND movie_id = ?", userDetails.ID, movie.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this movie", "review_id": existing.ID})
+		return
+	}
+	review := models.Review{
+		UserID:     userDetails.ID,
+		MovieID:    movie.ID,
+		Rating:     body.Rating,
+		Body:       body.Body,
+		IsVerified: hasWatchedMovie(userDetails.ID, movie.ID),
+		Status:     models.ReviewPublished,
+	}
+	if err := initializers.Db.Create(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"review": review})
+}
+
+func UpdateReview(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body ReviewRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var review models.Review
+	if err := initializers.Db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if review.UserID != userDetails.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own review"})
+		return
+	}
+	review.Rating = body.Rating
+	review.Body = body.Body
+	// The user may have watched the movie since first reviewing it
+	review.IsVerified = hasWatchedMovie(userDetails.ID, review.MovieID)
+	if err := initializers.Db.Save(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"review": review})
+}
+
+func DeleteReview(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var review models.Review
+	if err := initializers.Db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if review.UserID != userDetails.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own review"})
+		return
+	}
+	// Hard delete so the user can write a fresh review later
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_id = ?", review.ID).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&review).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted"})
+}
+
+func VoteReviewHelpful(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var review models.Review
+	if err := initializers.Db.Where("status = ?", models.ReviewPublished).First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if review.UserID == userDetails.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot vote on your own review"})
+		return
+	}
+	errAlreadyVoted := errors.New("already voted")
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		// The unique index settles two votes at once; only the one that went in counts
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ReviewVote{ReviewID: review.ID, UserID: userDetails.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyVoted
+		}
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+	if errors.Is(err, errAlreadyVoted) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already marked this review as helpful"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Marked as helpful"})
+}
+
+func RemoveReviewVote(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", c.Param("id"), userDetails.ID).Delete(&models.ReviewVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Review{}).Where("id = ?", c.Param("id")).
+			UpdateColumn("helpful_count", gorm.Expr("GREATEST(helpful_count - 1, 0)")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove vote"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Vote removed"})
+}
+
+// GetReviewsForModeration lists reviews of any status for admins
+func GetReviewsForModeration(c *gin.Context) {
+	limit := 20
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+	query := initializers.Db.Model(&models.Review{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("reviews.status = ?", status)
+	}
+	if movieID := c.Query("movie_id"); movieID != "" {
+		query = query.Where("reviews.movie_id = ?", movieID)
+	}
+	var reviews []ReviewResponse
+	query.Select("reviews.*, users.name AS user_name").
+		Joins("JOIN users ON users.id = reviews.user_id").
+		Order("reviews.created_at desc").Limit(limit).Offset(offset).
+		Scan(&reviews)
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews})
+}
+
+type ModerateReviewBody struct {
+	Status string `json:"status" validate:"required,oneof=published hidden"`
+	Note   string `json:"note"`
+}
+
+func ModerateReview(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body ModerateReviewBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var review models.Review
+	if err := initializers.Db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	now := time.Now()
+	review.Status = body.Status
+	review.ModerationNote = body.Note
+	review.ModeratedByID = &userDetails.ID
+	review.ModeratedAt = &now
+	if err := initializers.Db.Save(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to moderate review"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"review": review})
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// assignmentVenue loads the venue a venue-scoped assignment is for, nil for other scopes
+func assignmentVenue(a models.RoleAssignment) (*models.Venue, error) {
+	if a.VenueID == nil {
+		return nil, nil
+	}
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, *a.VenueID).Error; err != nil {
+		return nil, err
+	}
+	return &venue, nil
+}
+
+// roleAuditDetails is what the audit log keeps about a grant or revoke
+func roleAuditDetails(a models.RoleAssignment) map[string]interface{} {
+	return map[string]interface{}{"role": a.Role, "operator_id": a.OperatorID, "venue_id": a.VenueID, "assignment_id": a.ID}
+}
+
+// grantRole gives the target the role if the granter, the request's user, may hand it out, and records it in
+// the audit log. The returned status goes with the error.
+func grantRole(c *gin.Context, granter models.User, target *models.User, a models.RoleAssignment) (int, error) {
+	if err := a.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if a.OperatorID != nil {
+		if err := initializers.Db.First(&models.Operator{}, *a.OperatorID).Error; err != nil {
+			return http.StatusNotFound, errors.New("Operator not found")
+		}
+	}
+	venue, err := assignmentVenue(a)
+	if err != nil {
+		return http.StatusNotFound, errors.New("Venue not found")
+	}
+	if !granter.CanGrant(a, venue) {
+		return http.StatusForbidden, errors.New("You can't grant this role")
+	}
+	if err := helpers.LoadRoles(target); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to load roles")
+	}
+	for _, existing := range target.Roles {
+		if existing.SameGrant(a) {
+			return http.StatusConflict, errors.New("User already has this role")
+		}
+	}
+	a.UserID = target.ID
+	a.GrantedByID = &granter.ID
+	if err := initializers.Db.Create(&a).Error; err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to grant role")
+	}
+	target.SetRoles(append(target.Roles, a))
+	recordAudit(c, models.AuditRoleGranted, target.ID, roleAuditDetails(a))
+	return 0, nil
+}
+
+// revokeRole takes the assignment away if the granter could have handed it out, and records it in the audit log.
+// The last super admin can't be removed, so there's always someone who can grant roles.
+func revokeRole(c *gin.Context, granter models.User, a models.RoleAssignment) (int, error) {
+	venue, err := assignmentVenue(a)
+	if err != nil {
+		return http.StatusNotFound, errors.New("Venue not found")
+	}
+	if !granter.CanGrant(a, venue) {
+		return http.StatusForbidden, errors.New("You can't revoke this role")
+	}
+	if a.Role == models.RoleSuperAdmin {
+		var count int64
+		initializers.Db.Model(&models.RoleAssignment{}).Where("role = ? AND operator_id IS NULL AND venue_id IS NULL", models.RoleSuperAdmin).Count(&count)
+		if count <= 1 {
+			return http.StatusConflict, errors.New("Can't remove the last super admin")
+		}
+	}
+	if err := initializers.Db.Delete(&a).Error; err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to revoke role")
+	}
+	recordAudit(c, models.AuditRoleRevoked, a.UserID, roleAuditDetails(a))
+	return 0, nil
+}
+
+// GetRoleDefinitions lists the roles that can be granted, with their permissions and scopes
+func GetRoleDefinitions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"roles": models.Roles})
+}
+
+// GetUserRoles lists a user's role assignments. Admins without a global role see the ones within their scope.
+func GetUserRoles(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := helpers.LoadRoles(&target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roles"})
+		return
+	}
+	roles := make([]models.RoleAssignment, 0, len(target.Roles))
+	for _, a := range target.Roles {
+		if venue, err := assignmentVenue(a); err == nil && userDetails.CanGrant(a, venue) {
+			roles = append(roles, a)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": target.ID, "roles": roles})
+}
+
+type GrantRoleBody struct {
+	Role       string `json:"role" validate:"required"`
+	OperatorID *uint  `json:"operator_id"`
+	VenueID    *uint  `json:"venue_id"`
+}
+
+// GrantRole gives a user a role, everywhere or over one operator or venue as the role allows
+func GrantRole(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body GrantRoleBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	assignment := models.RoleAssignment{Role: body.Role, OperatorID: body.OperatorID, VenueID: body.VenueID}
+	if status, err := grantRole(c, userDetails, &target, assignment); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"user": target})
+}
+
+// RevokeRole takes one of a user's role assignments away
+func RevokeRole(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var assignment models.RoleAssignment
+	if err := initializers.Db.Where("user_id = ?", c.Param("id")).First(&assignment, c.Param("roleId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role assignment not found"})
+		return
+	}
+	if status, err := revokeRole(c, userDetails, assignment); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role revoked"})
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// canonicalFormats checks each format is one we know and returns them in canonical spelling without repeats
+func canonicalFormats(formats []string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, f := range formats {
+		format, ok := models.CanonicalFormat(f)
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q, use one of %s", f, strings.Join(models.ShowFormats, ", "))
+		}
+		if !seen[format] {
+			seen[format] = true
+			result = append(result, format)
+		}
+	}
+	return result, nil
+}
+
+// canonicalTags lowercases tags, checks each is one of known and drops repeats. kind names the tag in errors.
+func canonicalTags(tags, known []string, kind string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		tag := strings.ToLower(strings.TrimSpace(t))
+		if !slices.Contains(known, tag) {
+			return nil, fmt.Errorf("unknown %s %q, use one of %s", kind, t, strings.Join(known, ", "))
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+// showScreen works out the screen and format for a showtime at a venue.
+// Once a venue has screens every showtime needs one, and the screen has to support the format.
+// Venues without screens take any known format. The returned status goes with the error.
+func showScreen(venueID uint, screenID *uint, format string) (*models.Screen, string, int, error) {
+	canonical, ok := models.CanonicalFormat(format)
+	if !ok {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(models.ShowFormats, ", "))
+	}
+	if screenID == nil {
+		var screens int64
+		initializers.Db.Model(&models.Screen{}).Where("venue_id = ?", venueID).Count(&screens)
+		if screens > 0 {
+			return nil, "", http.StatusBadRequest, errors.New("screen_id is required for a venue with screens")
+		}
+		return nil, canonical, 0, nil
+	}
+	var screen models.Screen
+	if err := initializers.Db.Where("venue_id = ?", venueID).First(&screen, *screenID).Error; err != nil {
+		return nil, "", http.StatusNotFound, errors.New("Screen not found at this venue")
+	}
+	if !screen.SupportsFormat(canonical) {
+		return nil, "", http.StatusBadRequest, fmt.Errorf("screen %s does not support %s", screen.Name, canonical)
+	}
+	return &screen, canonical, 0, nil
+}
+
+// screenBusy reports whether another showtime already plays on the screen at that time.
+// Daily shows clash on the same HH:MM, dated shows on the same start.
+func screenBusy(screenID uint, timing string, startsAt *time.Time, excludeID uint) bool {
+	query := initializers.Db.Model(&models.ShowTime{}).Where("screen_id = ? AND id <> ?", screenID, excludeID)
+	if startsAt != nil {
+		query = query.Where("starts_at = ?", *startsAt)
+	} else {
+		query = query.Where("starts_at IS NULL AND timing = ?", timing)
+	}
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
+
+// upcomingShowTimes returns the screen's showtimes still ahead. Daily shows are always ahead.
+func upcomingShowTimes(screenID uint, now time.Time) []models.ShowTime {
+	var showTimes []models.ShowTime
+	initializers.Db.Where("screen_id = ? AND (starts_at IS NULL OR starts_at > ?)", screenID, now).Find(&showTimes)
+	return showTimes
+}
+
+func GetVenueScreens(c *gin.Context) {
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	var screens []models.Screen
+	initializers.Db.Where("venue_id = ?", venue.ID).Order("name").Find(&screens)
+	c.JSON(http.StatusOK, gin.H{"screens": screens})
+}
+
+type ScreenBody struct {
+	Name          string   `json:"name" validate:"required"`
+	Capacity      int      `json:"capacity" validate:"required,min=1,max=1000"` // Seats are laid out in rows A-Z
+	Formats       []string `json:"formats"`
+	Accessibility []string `json:"accessibility"`
+}
+
+func CreateScreen(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body ScreenBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	formats, err := canonicalFormats(body.Formats)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	accessibility, err := canonicalTags(body.Accessibility, models.AccessibilityFeatures, "accessibility feature")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageScreens, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	if screenNameTaken(venue.ID, body.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Venue already has a screen with that name"})
+		return
+	}
+	screen := models.Screen{
+		VenueID:       venue.ID,
+		Name:          strings.TrimSpace(body.Name),
+		Capacity:      body.Capacity,
+		Formats:       formats,
+		Accessibility: accessibility,
+	}
+	if err := initializers.Db.Create(&screen).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create screen"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"screen": screen})
+}
+
+func screenNameTaken(venueID uint, name string, excludeID uint) bool {
+	var count int64
+	initializers.Db.Model(&models.Screen{}).
+		Where("venue_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", venueID, strings.TrimSpace(name), excludeID).
+		Count(&count)
+	return count > 0
+}
+
+type UpdateScreenBody struct {
+	Name          *string   `json:"name" validate:"omitempty,min=1"`
+	Capacity      *int      `json:"capacity" validate:"omitempty,min=1,max=1000"`
+	Formats       *[]string `json:"formats"`
+	Accessibility *[]string `json:"accessibility"`
+}
+
+// UpdateScreen edits a screen. A new capacity applies to showtimes added afterwards, existing seat
+// layouts are left alone. Formats that upcoming showtimes on the screen rely on can't be removed.
+func UpdateScreen(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body UpdateScreenBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var screen models.Screen
+	if err := initializers.Db.Where("venue_id = ?", c.Param("id")).First(&screen, c.Param("screenId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Screen not found"})
+		return
+	}
+	var venue models.Venue
+	initializers.Db.First(&venue, screen.VenueID)
+	if !userDetails.CanForVenue(models.PermManageScreens, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	if body.Name != nil {
+		if screenNameTaken(screen.VenueID, *body.Name, screen.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Venue already has a screen with that name"})
+			return
+		}
+		screen.Name = strings.TrimSpace(*body.Name)
+	}
+	if body.Capacity != nil {
+		screen.Capacity = *body.Capacity
+	}
+	if body.Formats != nil {
+		formats, err := canonicalFormats(*body.Formats)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated := models.Screen{Formats: formats}
+		for _, st := range upcomingShowTimes(screen.ID, time.Now()) {
+			if !updated.SupportsFormat(st.Format) {
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Upcoming showtimes on this screen are in %s, move or cancel them first", st.Format)})
+				return
+			}
+		}
+		screen.Formats = formats
+	}
+	if body.Accessibility != nil {
+		accessibility, err := canonicalTags(*body.Accessibility, models.AccessibilityFeatures, "accessibility feature")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		screen.Accessibility = accessibility
+	}
+	if err := initializers.Db.Save(&screen).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update screen"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"screen": screen})
+}
+
+// DeleteScreen soft deletes a screen with no upcoming showtimes. Past showtimes keep pointing at it.
+func DeleteScreen(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var screen models.Screen
+	if err := initializers.Db.Where("venue_id = ?", c.Param("id")).First(&screen, c.Param("screenId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Screen not found"})
+		return
+	}
+	var venue models.Venue
+	initializers.Db.First(&venue, screen.VenueID)
+	if !userDetails.CanForVenue(models.PermManageScreens, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	if upcoming := upcomingShowTimes(screen.ID, time.Now()); len(upcoming) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              "Screen has upcoming showtimes, move or cancel them first",
+			"upcoming_showtimes": len(upcoming),
+		})
+		return
+	}
+	if err := initializers.Db.Delete(&screen).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete screen"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Screen deleted"})
+}
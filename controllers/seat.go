@@ -15,7 +15,7 @@ func GetSeatLayout(c *gin.Context) {
 	showtimeID := c.Param("id")
 	// Fetch the showtime with venue and movie
 	var showTime models.ShowTime
-	if err := initializers.Db.Preload("Seats").Preload("Venue").Preload("Movie").First(&showTime, showtimeID).Error; err != nil {
+	if err := initializers.Db.Preload("Seats").Preload("Venue").Preload("Venue.City.Region").Preload("Movie").Preload("Screen").First(&showTime, showtimeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "ShowTime not found"})
 		return
 	}
@@ -30,20 +30,66 @@ func GetSeatLayout(c *gin.Context) {
 	if showTime.Movie.ID != 0 {
 		movieName = showTime.Movie.Title
 	}
+	screenName := ""
+	if showTime.Screen != nil {
+		screenName = showTime.Screen.Name
+	}
+	currency := ""
+	if showTime.Venue.City != nil {
+		currency = showTime.Venue.City.Settings().Currency
+	}
+
+	// The price tier of the ?seat_category asked for, or the user's preferred one, for apps to highlight
+	category := c.Query("seat_category")
+	if user, ok := c.Get("user"); ok && category == "" {
+		category = user.(models.User).Preferences.SeatCategory
+	}
+	var preferredPrice *float32
+	prices := make([]float32, 0, len(showTime.Seats))
+	for _, seat := range showTime.Seats {
+		prices = append(prices, seat.Price)
+	}
+	if price, ok := models.SeatCategoryPrice(prices, category); ok {
+		preferredPrice = &price
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"showtime":   showTime.Timing,
-		"venue":      showTime.VenueID,
-		"venue_name": venueName,
-		"movie_name": movieName,
-		"seats":      seatMatrix,
+		"showtime":        showTime.Timing,
+		"venue":           showTime.VenueID,
+		"venue_name":      venueName,
+		"movie_name":      movieName,
+		"screen":          screenName,
+		"format":          showTime.Format,
+		"currency":        currency,
+		"seats":           seatMatrix,
+		"seat_category":   category,
+		"preferred_price": preferredPrice,
 	})
 }
 
+// showTimeBookable checks the showtime's movie is in a stage that takes bookings
+func showTimeBookable(showID uint) (bool, string) {
+	var showTime models.ShowTime
+	if err := initializers.Db.Preload("Movie").First(&showTime, showID).Error; err != nil {
+		return false, "ShowTime not found"
+	}
+	if !showTime.Movie.IsBookable(time.Now()) {
+		if showTime.Movie.Status == models.StageArchived {
+			return false, "This movie is no longer showing"
+		}
+		return false, "Booking has not opened for this movie yet"
+	}
+	return true, ""
+}
+
 func ReserveSeats(c *gin.Context) {
 	user, _ := c.Get("user")
 	userDetails := user.(models.User)
 	userID := userDetails.ID
+	if !userDetails.CanBook(time.Now(), helpers.EmailVerificationGrace()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email before booking"})
+		return
+	}
 
 	var request struct {
 		ShowID uint   `json:"show_id"`
@@ -64,6 +110,11 @@ func ReserveSeats(c *gin.Context) {
 		uniqueIDs[id] = true
 	}
 
+	if ok, msg := showTimeBookable(request.ShowID); !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return
+	}
+
 	// Start a GORM transaction
 	tx := initializers.Db.Begin()
 
@@ -134,6 +185,15 @@ func BookSeats(c *gin.Context) {
 	user, _ := c.Get("user")
 	userDetails := user.(models.User)
 	userId := userDetails.ID
+	if !userDetails.CanBook(time.Now(), helpers.EmailVerificationGrace()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email before booking"})
+		return
+	}
+
+	if ok, msg := showTimeBookable(request.ShowID); !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return
+	}
 
 	// Start a GORM transaction
 	tx := initializers.Db.Begin()
@@ -178,6 +238,7 @@ func BookSeats(c *gin.Context) {
 		UserID:     userId,
 		ShowTimeID: request.ShowID,
 		TotalPrice: totalPrice,
+		Status:     models.OrderConfirmed,
 		Seats:      seats,
 	}
 
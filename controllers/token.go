@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refreshCookiePath keeps the refresh cookie off every request but the ones that need it
+const refreshCookiePath = "/user/token"
+
+// tokenPair is what a login or refresh hands back
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// issueTokens signs an access token and stores a new refresh token in the family, one per login
+func issueTokens(tx *gorm.DB, userID uint, familyID string) (tokenPair, error) {
+	now := time.Now()
+	jti := helpers.NewTokenID()
+	expiresAt := now.Add(helpers.AccessTokenTTL())
+	access, err := helpers.SignAccessToken(userID, familyID, jti, expiresAt)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	refresh, hash := helpers.NewSecretToken()
+	row := models.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       hash,
+		ExpiresAt:       now.Add(helpers.RefreshTokenTTL()),
+		AccessJTI:       jti,
+		AccessExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return tokenPair{}, err
+	}
+	return tokenPair{AccessToken: access, RefreshToken: refresh, ExpiresAt: expiresAt}, nil
+}
+
+// startSession records a login from this request and issues its first tokens
+func startSession(c *gin.Context, userID uint) (tokenPair, error) {
+	var pair tokenPair
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session := models.Session{
+			UserID:     userID,
+			FamilyID:   helpers.NewTokenID(),
+			UserAgent:  c.Request.UserAgent(),
+			IP:         c.ClientIP(),
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(helpers.RefreshTokenTTL()),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = issueTokens(tx, userID, session.FamilyID)
+		return err
+	})
+	return pair, err
+}
+
+// respondWithTokens sets the cookies for the web and returns the tokens in the body for mobile apps
+func respondWithTokens(c *gin.Context, status int, pair tokenPair, body gin.H) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("Authorization", pair.AccessToken, int(helpers.AccessTokenTTL().Seconds()), "", "", false, true)
+	c.SetCookie("RefreshToken", pair.RefreshToken, int(helpers.RefreshTokenTTL().Seconds()), refreshCookiePath, "", false, true)
+	body["token"] = pair.AccessToken
+	body["refresh_token"] = pair.RefreshToken
+	body["expires_in"] = int(time.Until(pair.ExpiresAt).Seconds())
+	c.JSON(status, body)
+}
+
+func clearTokenCookies(c *gin.Context) {
+	c.SetCookie("Authorization", "", -1, "", "", false, true)
+	c.SetCookie("RefreshToken", "", -1, refreshCookiePath, "", false, true)
+}
+
+// revokeTokens revokes the sessions and refresh tokens the scope matches and denylists the access tokens issued with them
+// that haven't expired yet, so they stop working on the next request
+func revokeTokens(scope func(*gorm.DB) *gorm.DB) error {
+	now := time.Now()
+	return initializers.Db.Transaction(func(tx *gorm.DB) error {
+		var rows []models.RefreshToken
+		if err := tx.Scopes(scope).Where("revoked_at IS NULL").Find(&rows).Error; err != nil {
+			return err
+		}
+		var denied []models.RevokedToken
+		for _, r := range rows {
+			if entry, ok := r.AccessDenial(now); ok {
+				denied = append(denied, entry)
+			}
+		}
+		if len(denied) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&denied).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Session{}).Scopes(scope).Where("revoked_at IS NULL").Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).Scopes(scope).Where("revoked_at IS NULL").Update("revoked_at", now).Error
+	})
+}
+
+// revokeFamily signs out one login
+func revokeFamily(familyID string) error {
+	return revokeTokens(func(db *gorm.DB) *gorm.DB { return db.Where("family_id = ?", familyID) })
+}
+
+// revokeUserTokens signs the user out everywhere
+func revokeUserTokens(userID uint) error {
+	return revokeTokens(func(db *gorm.DB) *gorm.DB { return db.Where("user_id = ?", userID) })
+}
+
+// RefreshTokens swaps a refresh token, from the body or the RefreshToken cookie, for a new access and
+// refresh token. Each refresh token works once: presenting one that was already swapped means it was
+// copied, so the whole login is revoked.
+func RefreshTokens(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	c.ShouldBindJSON(&body)
+	if body.RefreshToken == "" {
+		body.RefreshToken, _ = c.Cookie("RefreshToken")
+	}
+	if body.RefreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token required"})
+		return
+	}
+
+	var current models.RefreshToken
+	if err := initializers.Db.Where("token_hash = ?", helpers.HashToken(body.RefreshToken)).First(&current).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if current.RevokedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token revoked"})
+		return
+	}
+	if current.UsedAt != nil {
+		revokeFamily(current.FamilyID)
+		clearTokenCookies(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		return
+	}
+	if time.Now().After(current.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	var pair tokenPair
+	reused := false
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		// Only one of two concurrent refreshes with the same token gets to swap it
+		result := tx.Model(&models.RefreshToken{}).Where("id = ? AND used_at IS NULL", current.ID).Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return nil
+		}
+		var err error
+		if pair, err = issueTokens(tx, current.UserID, current.FamilyID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).Where("family_id = ?", current.FamilyID).
+			Updates(map[string]interface{}{"last_seen_at": time.Now(), "expires_at": time.Now().Add(helpers.RefreshTokenTTL())}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	if reused {
+		revokeFamily(current.FamilyID)
+		clearTokenCookies(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		return
+	}
+	respondWithTokens(c, http.StatusOK, pair, gin.H{"message": "Token refreshed"})
+}
+
+// RevokeUserSessions signs a user out of every device, e.g. after their account was compromised
+func RevokeUserSessions(c *gin.Context) {
+	target, ok := managedUser(c)
+	if !ok {
+		return
+	}
+	if err := revokeUserTokens(target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	recordAudit(c, models.AuditSessionsRevoked, target.ID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked"})
+}
+
+// GetSessions lists the devices the user is logged in on, with the one making the request marked current
+func GetSessions(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+
+	var sessions []models.Session
+	initializers.Db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userDetails.ID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions)
+	result := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"user_agent":   s.UserAgent,
+			"ip":           s.IP,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.FamilyID == claims.SessionID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": result})
+}
+
+// RevokeSession signs the user out on one device. Its tokens stop working on their next request.
+func RevokeSession(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+
+	var session models.Session
+	if err := initializers.Db.Where("user_id = ? AND revoked_at IS NULL", userDetails.ID).First(&session, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err := revokeFamily(session.FamilyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if session.FamilyID == claims.SessionID {
+		clearTokenCookies(c)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
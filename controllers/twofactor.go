@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	totpIssuer          = "BookMyShow"
+	loginChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount   = 10
+	maxTwoFactorFailure = 5
+	twoFactorLockout    = 15 * time.Minute
+)
+
+var errInvalidRecoveryCode = errors.New("invalid recovery code")
+
+// completeLogin finishes a login whose first factor checked out. Users with two-factor authentication
+// get a challenge token for POST /user/login/2fa instead of a session. Disabled accounts get neither.
+func completeLogin(c *gin.Context, user models.User) {
+	if user.IsDisabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account has been disabled"})
+		return
+	}
+	if user.HasTwoFactor() {
+		challenge, err := helpers.SignLoginChallenge(user.ID, time.Now().Add(loginChallengeTTL))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Enter the code from your authenticator app",
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+		})
+		return
+	}
+	pair, err := startSession(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	// Roles come with the user so the admin panel can tell who may sign in to it
+	helpers.LoadRoles(&user)
+	// A short-lived access token plus a refresh token, as cookies for web and in the body for mobile apps
+	respondWithTokens(c, http.StatusOK, pair, gin.H{
+		"message": "Login successful",
+		"user":    user,
+	})
+}
+
+// checkSecondFactor accepts a TOTP code or one of the user's recovery codes, using either up
+func checkSecondFactor(user *models.User, code, recoveryCode string) bool {
+	if code != "" {
+		step, ok := helpers.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false
+		}
+		// Recording the step only if it's newer means a code seen twice at once still works once
+		result := initializers.Db.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).Update("totp_last_step", step)
+		user.TOTPLastStep = step
+		return result.Error == nil && result.RowsAffected == 1
+	}
+	if recoveryCode != "" {
+		hash := helpers.HashToken(helpers.NormalizeRecoveryCode(recoveryCode))
+		// The codes are read again under a row lock, so the same code used twice at once only works once
+		err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+			var locked models.User
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "recovery_codes").First(&locked, user.ID).Error; err != nil {
+				return err
+			}
+			i := slices.Index(locked.RecoveryCodes, hash)
+			if i < 0 {
+				return errInvalidRecoveryCode
+			}
+			user.RecoveryCodes = slices.Delete(locked.RecoveryCodes, i, i+1)
+			return tx.Model(&locked).Select("recovery_codes").Updates(models.User{RecoveryCodes: user.RecoveryCodes}).Error
+		})
+		return err == nil
+	}
+	return false
+}
+
+type SecondFactorBody struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// LoginTwoFactor is the second step of a login for users with two-factor authentication
+func LoginTwoFactor(c *gin.Context) {
+	var body struct {
+		ChallengeToken string `json:"challenge_token" validate:"required"`
+		SecondFactorBody
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	userID, err := helpers.ParseLoginChallenge(body.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please log in again"})
+		return
+	}
+	var user models.User
+	if err := initializers.Db.First(&user, userID).Error; err != nil || !user.HasTwoFactor() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please log in again"})
+		return
+	}
+	if user.IsDisabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account has been disabled"})
+		return
+	}
+	if user.TwoFactorLockedUntil != nil && time.Now().Before(*user.TwoFactorLockedUntil) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many wrong codes, try again later",
+			"retry_after": int(time.Until(*user.TwoFactorLockedUntil).Seconds()) + 1,
+		})
+		return
+	}
+	// Counting the attempt before checking means parallel guesses can't get past the limit
+	now := time.Now()
+	result := initializers.Db.Model(&models.User{}).
+		Where("id = ? AND two_factor_failures < ? AND (two_factor_locked_until IS NULL OR two_factor_locked_until < ?)", user.ID, maxTwoFactorFailure, now).
+		UpdateColumn("two_factor_failures", gorm.Expr("two_factor_failures + 1"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many wrong codes, try again later"})
+		return
+	}
+	if !checkSecondFactor(&user, body.Code, body.RecoveryCode) {
+		// The attempt that used up the limit locks the account, starting the count again once it's over
+		initializers.Db.Model(&models.User{}).Where("id = ? AND two_factor_failures >= ?", user.ID, maxTwoFactorFailure).
+			Updates(map[string]interface{}{"two_factor_failures": 0, "two_factor_locked_until": now.Add(twoFactorLockout)})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	initializers.Db.Model(&user).Updates(map[string]interface{}{"two_factor_failures": 0, "two_factor_locked_until": nil})
+
+	pair, err := startSession(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	// Roles come with the user so the admin panel can tell who may sign in to it
+	helpers.LoadRoles(&user)
+	response := gin.H{"message": "Login successful", "user": user}
+	if body.RecoveryCode != "" {
+		response["recovery_codes_left"] = len(user.RecoveryCodes)
+	}
+	respondWithTokens(c, http.StatusOK, pair, response)
+}
+
+// SetupTwoFactor starts enrolment with a new secret. It has no effect until confirmed with EnableTwoFactor.
+func SetupTwoFactor(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	if userDetails.HasTwoFactor() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already on"})
+		return
+	}
+	secret := helpers.NewTOTPSecret()
+	if err := initializers.Db.Model(&userDetails).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": helpers.TOTPProvisioningURI(totpIssuer, userDetails.Email, secret),
+	})
+}
+
+// EnableTwoFactor turns on two-factor authentication once the user enters a code from the new secret.
+// The recovery codes are returned this once. Other sessions are signed out, as they never gave a code.
+func EnableTwoFactor(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+
+	var body struct {
+		Code string `json:"code" validate:"required,numeric,len=6"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if userDetails.HasTwoFactor() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already on"})
+		return
+	}
+	if userDetails.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set up two-factor authentication first"})
+		return
+	}
+	step, ok := helpers.ValidateTOTP(userDetails.TOTPSecret, body.Code, time.Now(), 0)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	codes, hashes := helpers.NewRecoveryCodes(recoveryCodeCount)
+	now := time.Now()
+	err := initializers.Db.Model(&userDetails).Select("totp_enabled_at", "totp_last_step", "recovery_codes").
+		Updates(models.User{TOTPEnabledAt: &now, TOTPLastStep: step, RecoveryCodes: hashes}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to turn on two-factor authentication"})
+		return
+	}
+	revokeTokens(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? AND family_id <> ?", userDetails.ID, claims.SessionID)
+	})
+	helpers.Notify(userDetails, "Two-factor authentication is on", "Logging in to your account now needs a code from your authenticator app.")
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication is on. Keep these recovery codes somewhere safe, each works once.",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns two-factor authentication off with the password and a code. Accounts without a
+// password, from a provider sign-up or a forced reset, confirm with the code alone. Admins can't when
+// ADMIN_2FA_REQUIRED is set.
+func DisableTwoFactor(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body struct {
+		Password string `json:"password"`
+		SecondFactorBody
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if !userDetails.HasTwoFactor() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already off"})
+		return
+	}
+	if userDetails.IsStaff() && helpers.AdminTwoFactorRequired() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Staff must keep two-factor authentication on"})
+		return
+	}
+	passwordOK := userDetails.NoPassword || bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(body.Password)) == nil
+	if !passwordOK || !checkSecondFactor(&userDetails, body.Code, body.RecoveryCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password or code is incorrect"})
+		return
+	}
+	err := initializers.Db.Model(&userDetails).Select("totp_secret", "totp_enabled_at", "totp_last_step", "recovery_codes").
+		Updates(models.User{RecoveryCodes: []string{}}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to turn off two-factor authentication"})
+		return
+	}
+	helpers.Notify(userDetails, "Two-factor authentication is off", "Logging in to your account no longer needs a code. If this wasn't you, reset your password.")
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication is off"})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, e.g. once most have been used
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body struct {
+		Code string `json:"code" validate:"required,numeric,len=6"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	if !userDetails.HasTwoFactor() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is off"})
+		return
+	}
+	if !checkSecondFactor(&userDetails, body.Code, "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	codes, hashes := helpers.NewRecoveryCodes(recoveryCodeCount)
+	if err := initializers.Db.Model(&userDetails).Select("recovery_codes").Updates(models.User{RecoveryCodes: hashes}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
@@ -2,12 +2,10 @@ package controllers
 
 import (
 	"net/http"
-	"os"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
 	"golang.org/x/crypto/bcrypt"
@@ -19,7 +17,7 @@ type UserRequestBody struct {
 	Name        string `json:"name" validate:"required,min=2,max=50"`
 	Email       string `json:"email" validate:"email,required"`
 	Password    string `json:"password" validate:"required"`
-	PhoneNumber string `json:"phone_number"` // Optional - no validation
+	PhoneNumber string `json:"phone_number"` // Optional, verified separately with POST /user/phone/otp
 }
 
 func SignUp(c *gin.Context) {
@@ -35,6 +33,16 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	// Phone numbers are kept in E.164 and only used to log in once verified with a code
+	if body.PhoneNumber != "" {
+		phone, err := normalizePhone(body.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body.PhoneNumber = phone
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), 10)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash the password"})
@@ -46,7 +54,6 @@ func SignUp(c *gin.Context) {
 		Email:       body.Email,
 		Password:    string(hash),
 		PhoneNumber: body.PhoneNumber,
-		IsAdmin:     false,
 	}
 
 	// Check if email already exists
@@ -63,6 +70,9 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	// New accounts can't book until they confirm the address belongs to them
+	sendVerificationEmail(&user)
+
 	// Return the created user as a response
 	c.JSON(http.StatusCreated, gin.H{
 		"user": user,
@@ -99,27 +109,7 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,                                   // Subject (User ID)
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(), // Token expiration time
-	})
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
-		return
-	}
-
-	// Set the token in a cookie (for web)
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("Authorization", tokenString, 3600*24*7, "", "", false, true)
-
-	// Also return token in body for mobile apps (Bearer auth)
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user,
-		"token":   tokenString,
-	})
+	completeLogin(c, user)
 }
 
 func GetMe(c *gin.Context) {
@@ -128,10 +118,21 @@ func GetMe(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+		// Admin endpoints stay closed to this admin until they set up two-factor authentication
+		"two_factor_enrolment_required": c.GetBool("two_factor_enrolment_required"),
+	})
 }
 
+// Logout revokes the login's refresh tokens and its current access token
 func Logout(c *gin.Context) {
-	c.SetCookie("Authorization", "", -1, "", "", false, true)
+	token, _ := c.Get("token")
+	claims := token.(helpers.AccessClaims)
+	if err := revokeFamily(claims.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	clearTokenCookies(c)
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
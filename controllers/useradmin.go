@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loadRolesFor sets the roles of every user in one query, for listings
+func loadRolesFor(users []models.User) error {
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	var assignments []models.RoleAssignment
+	if err := initializers.Db.Where("user_id IN ?", ids).Order("id").Find(&assignments).Error; err != nil {
+		return err
+	}
+	byUser := map[uint][]models.RoleAssignment{}
+	for _, a := range assignments {
+		byUser[a.UserID] = append(byUser[a.UserID], a)
+	}
+	for i := range users {
+		users[i].SetRoles(byUser[users[i].ID])
+	}
+	return nil
+}
+
+// managedUser loads the user from the :id param for an account action, checking the request's user may take it
+func managedUser(c *gin.Context) (models.User, bool) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return target, false
+	}
+	if err := helpers.LoadRoles(&target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roles"})
+		return target, false
+	}
+	if !userDetails.CanManageAccount(target) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't change this account"})
+		return target, false
+	}
+	return target, true
+}
+
+// SearchUsers finds users by ?q (an ID, or part of a name, email or phone number), narrowed with ?role
+// (customer for users without one) and ?disabled=true|false
+func SearchUsers(c *gin.Context) {
+	limit := 20
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil {
+		offset = o
+	}
+	query := initializers.Db.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := "%" + q + "%"
+		if id, err := strconv.ParseUint(q, 10, 64); err == nil {
+			query = query.Where("id = ? OR phone_number ILIKE ?", id, pattern)
+		} else {
+			query = query.Where("name ILIKE ? OR email ILIKE ? OR phone_number ILIKE ?", pattern, pattern, pattern)
+		}
+	}
+	switch role := c.Query("role"); role {
+	case "":
+	case models.RoleCustomer:
+		query = query.Where("id NOT IN (?)", initializers.Db.Model(&models.RoleAssignment{}).Select("user_id"))
+	default:
+		if _, ok := models.LookupRole(role); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+			return
+		}
+		query = query.Where("id IN (?)", initializers.Db.Model(&models.RoleAssignment{}).Select("user_id").Where("role = ?", role))
+	}
+	switch c.Query("disabled") {
+	case "true":
+		query = query.Where("disabled_at IS NOT NULL")
+	case "false":
+		query = query.Where("disabled_at IS NULL")
+	}
+	var total int64
+	query.Count(&total)
+
+	var users []models.User
+	if err := query.Order("id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	if err := loadRolesFor(users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roles"})
+		return
+	}
+	nextOffset := offset + limit
+	if nextOffset >= int(total) {
+		nextOffset = -1
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "total": total, "next_offset": nextOffset})
+}
+
+// GetUserForAdmin shows a user's account with their roles, linked providers and activity counts
+func GetUserForAdmin(c *gin.Context) {
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := helpers.LoadRoles(&target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roles"})
+		return
+	}
+	var identities []models.Identity
+	initializers.Db.Where("user_id = ?", target.ID).Order("created_at").Find(&identities)
+	var orders, sessions int64
+	initializers.Db.Model(&models.Order{}).Where("user_id = ?", target.ID).Count(&orders)
+	initializers.Db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", target.ID, time.Now()).Count(&sessions)
+	c.JSON(http.StatusOK, gin.H{
+		"user":            target,
+		"identities":      identities,
+		"orders":          orders,
+		"active_sessions": sessions,
+	})
+}
+
+// GetUserOrdersForAdmin lists a user's orders the way they see them in their order history
+func GetUserOrdersForAdmin(c *gin.Context) {
+	var target models.User
+	if err := initializers.Db.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var orders []models.Order
+	if err := initializers.Db.Where("user_id = ?", target.ID).Preload("Seats").Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load orders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": target.ID, "orders": buildOrderResponses(orders)})
+}
+
+// DisableUser stops a user logging in and signs them out everywhere, until EnableUser
+func DisableUser(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" validate:"required,max=500"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	target, ok := managedUser(c)
+	if !ok {
+		return
+	}
+	if target.IsDisabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is already disabled"})
+		return
+	}
+	now := time.Now()
+	err := initializers.Db.Model(&target).Updates(map[string]interface{}{"disabled_at": now, "disabled_reason": body.Reason}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable account"})
+		return
+	}
+	target.DisabledAt, target.DisabledReason = &now, body.Reason
+	recordAudit(c, models.AuditUserDisabled, target.ID, map[string]interface{}{"reason": body.Reason})
+	// Their tokens would be refused anyway, revoking them keeps their sessions list honest
+	if err := revokeUserTokens(target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Account disabled but failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account disabled", "user": target})
+}
+
+// EnableUser lets a disabled user log in again
+func EnableUser(c *gin.Context) {
+	target, ok := managedUser(c)
+	if !ok {
+		return
+	}
+	if !target.IsDisabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is not disabled"})
+		return
+	}
+	previous := target.DisabledReason
+	if err := initializers.Db.Model(&target).Updates(map[string]interface{}{"disabled_at": nil, "disabled_reason": ""}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable account"})
+		return
+	}
+	target.DisabledAt, target.DisabledReason = nil, ""
+	recordAudit(c, models.AuditUserEnabled, target.ID, map[string]interface{}{"disabled_reason": previous})
+	c.JSON(http.StatusOK, gin.H{"message": "Account enabled", "user": target})
+}
+
+// ForcePasswordReset makes the user choose a new password, e.g. when theirs may have leaked. The old one
+// stops working, every session is signed out and the user is emailed a reset link.
+func ForcePasswordReset(c *gin.Context) {
+	target, ok := managedUser(c)
+	if !ok {
+		return
+	}
+	password, _ := helpers.NewSecretToken()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash the password"})
+		return
+	}
+	if err := initializers.Db.Model(&target).Updates(map[string]interface{}{"password": string(hash), "no_password": true}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	recordAudit(c, models.AuditPasswordResetForce, target.ID, nil)
+	if err := revokeUserTokens(target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	err = sendPasswordReset(target, "For your security, our support team has reset your password and signed you out. "+
+		"Use this link within an hour to choose a new one: %s")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password reset but failed to send the reset link"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset, the user has been emailed a link to choose a new one"})
+}
@@ -1,17 +1,64 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/Snehil208001/BookMyShowApp/helpers"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// venueFilterScope reads the venue search filters from the query: amenities=parking,recliners
+// (the venue must have all of them), cancellable=true and operator_id.
+func venueFilterScope(c *gin.Context) (func(*gorm.DB) *gorm.DB, error) {
+	var amenities []string
+	if a := c.Query("amenities"); a != "" {
+		var err error
+		if amenities, err = canonicalTags(strings.Split(a, ","), models.VenueAmenities, "amenity"); err != nil {
+			return nil, err
+		}
+	}
+	cancellable := c.Query("cancellable") == "true"
+	var operatorID uint64
+	if o := c.Query("operator_id"); o != "" {
+		var err error
+		if operatorID, err = strconv.ParseUint(o, 10, 64); err != nil {
+			return nil, errors.New("Invalid operator_id")
+		}
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if len(amenities) > 0 {
+			tags, _ := json.Marshal(amenities)
+			db = db.Where("venues.amenities::jsonb @> ?::jsonb", string(tags))
+		}
+		if cancellable {
+			db = db.Where("(venues.cancellation_policy::jsonb ->> 'allow_cancellation')::boolean")
+		}
+		if operatorID != 0 {
+			db = db.Where("venues.operator_id = ?", operatorID)
+		}
+		return db
+	}, nil
+}
+
+// GetAllVenues lists venues in the request's city, see requestCity
 func GetAllVenues(c *gin.Context) {
+	city, status, err := requestCity(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 	var venues []models.Venue
 	limit := 5
 	l := c.Query("limit")
@@ -29,10 +76,15 @@ func GetAllVenues(c *gin.Context) {
 			offset = parsedOffset
 		}
 	}
-	initializers.Db.
+	filters, err := venueFilterScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	initializers.Db.Scopes(venueCityScope(city), filters).
 		Limit(limit).Offset(offset).Find(&venues)
 	var totalVenues int64
-	initializers.Db.Model(&models.Venue{}).Count(&totalVenues)
+	initializers.Db.Model(&models.Venue{}).Scopes(venueCityScope(city), filters).Count(&totalVenues)
 	nextOffset := offset + limit
 	if nextOffset >= int(totalVenues) {
 		nextOffset = -1 // No more venues to load
@@ -43,9 +95,147 @@ func GetAllVenues(c *gin.Context) {
 	})
 }
 
+// Radius limits for nearby searches, in kilometres
+const (
+	defaultRadiusKm = 10
+	maxRadiusKm     = 200
+)
+
+// geoFilter is a lat/lng/radius search taken from the query string
+type geoFilter struct {
+	Lat, Lng, RadiusKm float64
+}
+
+// parseGeoFilter reads lat, lng and radius (km) from the query. It returns nil when lat and lng are both absent.
+func parseGeoFilter(c *gin.Context) (*geoFilter, error) {
+	latStr, lngStr := c.Query("lat"), c.Query("lng")
+	if latStr == "" && lngStr == "" {
+		return nil, nil
+	}
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, errors.New("lat must be a number between -90 and 90")
+	}
+	lng, err := strconv.ParseFloat(lngStr, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return nil, errors.New("lng must be a number between -180 and 180")
+	}
+	radius := float64(defaultRadiusKm)
+	if r := c.Query("radius"); r != "" {
+		radius, err = strconv.ParseFloat(r, 64)
+		if err != nil || radius <= 0 || radius > maxRadiusKm {
+			return nil, fmt.Errorf("radius must be a number of km between 0 and %d", maxRadiusKm)
+		}
+	}
+	return &geoFilter{Lat: lat, Lng: lng, RadiusKm: radius}, nil
+}
+
+// NearbyVenue is a venue with its distance from the search point
+type NearbyVenue struct {
+	models.Venue
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// venuesWithin returns the venues inside the filter's radius and city scope, nearest first.
+// A bounding box narrows the rows in SQL, then the exact distance is worked out here.
+func venuesWithin(filter geoFilter, city *models.City, scopes ...func(*gorm.DB) *gorm.DB) ([]NearbyVenue, error) {
+	minLat, maxLat, minLng, maxLng := helpers.BoundingBox(filter.Lat, filter.Lng, filter.RadiusKm)
+	var venues []models.Venue
+	err := initializers.Db.Scopes(append(scopes, venueCityScope(city))...).
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", minLat, maxLat, minLng, maxLng).
+		Find(&venues).Error
+	if err != nil {
+		return nil, err
+	}
+	nearby := []NearbyVenue{}
+	for _, v := range venues {
+		distance := helpers.DistanceKm(filter.Lat, filter.Lng, *v.Latitude, *v.Longitude)
+		if distance <= filter.RadiusKm {
+			nearby = append(nearby, NearbyVenue{Venue: v, DistanceKm: math.Round(distance*100) / 100})
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+	return nearby, nil
+}
+
+// venueIDs lists the IDs of the venues, for narrowing other queries
+func venueIDs(venues []NearbyVenue) []uint {
+	ids := make([]uint, len(venues))
+	for i, v := range venues {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+// GetNearbyVenues lists venues within radius km (default 10) of lat/lng, nearest first
+func GetNearbyVenues(c *gin.Context) {
+	city, status, err := requestCity(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseGeoFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng are required"})
+		return
+	}
+	limit := 20
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	filters, err := venueFilterScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	venues, err := venuesWithin(*filter, city, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search venues"})
+		return
+	}
+	total := len(venues)
+	if len(venues) > limit {
+		venues = venues[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"venues": venues,
+		"total":  total,
+	})
+}
+
 type VenueRequestBody struct {
 	Name     string `json:"name" validate:"required"`
-	Location string `json:"location" validate:"required"`
+	Location string `json:"location" validate:"required_without=CityID"` // Defaults to the city's name
+
+	AddressLine1 string   `json:"address_line1"`
+	AddressLine2 string   `json:"address_line2"`
+	Locality     string   `json:"locality"`
+	PostalCode   string   `json:"postal_code"`
+	CityID       *uint    `json:"city_id"`
+	Latitude     *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+	// Operator admins create venues for their own operator, picking one when they manage several
+	OperatorID *uint `json:"operator_id"`
+
+	Amenities          []string                   `json:"amenities"`
+	CancellationPolicy *models.CancellationPolicy `json:"cancellation_policy"`
+	OperatingHours     *models.OperatingHours     `json:"operating_hours"`
+}
+
+// venueCity looks up the city a venue is being put in, nil when none is given
+func venueCity(cityID *uint) (*models.City, error) {
+	if cityID == nil {
+		return nil, nil
+	}
+	var city models.City
+	if err := initializers.Db.First(&city, *cityID).Error; err != nil {
+		return nil, err
+	}
+	return &city, nil
 }
 
 func CreateVenue(c *gin.Context) {
@@ -62,15 +252,58 @@ func CreateVenue(c *gin.Context) {
 	user, _ := c.Get("user")
 	//We get userDetails, because we need to check that we are admin or not
 	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
+	operatorID := body.OperatorID
+	if !userDetails.CanGlobally(models.PermManageVenues) {
+		if ids := userDetails.OperatorsWith(models.PermManageVenues); operatorID == nil && len(ids) == 1 {
+			operatorID = &ids[0]
+		}
+		if operatorID == nil || !userDetails.CanForOperator(models.PermManageVenues, *operatorID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only create venues for an operator you manage"})
+			return
+		}
+	} else if operatorID != nil {
+		if err := initializers.Db.First(&models.Operator{}, *operatorID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+			return
+		}
+	}
+	city, err := venueCity(body.CityID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+		return
+	}
+	amenities, err := canonicalTags(body.Amenities, models.VenueAmenities, "amenity")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if body.OperatingHours != nil {
+		if err := body.OperatingHours.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	venue := models.Venue{
-		Name:     body.Name,
-		Location: body.Location,
+		Name:         body.Name,
+		Location:     body.Location,
+		AddressLine1: body.AddressLine1,
+		AddressLine2: body.AddressLine2,
+		Locality:     body.Locality,
+		PostalCode:   body.PostalCode,
+		CityID:       body.CityID,
+		City:         city,
+		OperatorID:   operatorID,
+		Latitude:     body.Latitude,
+		Longitude:    body.Longitude,
+
+		Amenities:          amenities,
+		CancellationPolicy: body.CancellationPolicy,
+		OperatingHours:     body.OperatingHours,
+	}
+	if venue.Location == "" {
+		venue.Location = city.Name
 	}
-	result := initializers.Db.Create(&venue)
+	result := initializers.Db.Omit("City").Create(&venue)
 	if result.Error != nil {
 		c.Status(http.StatusBadRequest)
 		return
@@ -104,16 +337,16 @@ func AddMoviesInVenue(c *gin.Context) {
 	user, _ := c.Get("user")
 	//We get userDetails, because we need to check that we are admin or not
 	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
-		return
-	}
 	//Check if venue exists
 	var venue models.Venue
 	if err := initializers.Db.First(&venue, venueID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
+	if !userDetails.CanForVenue(models.PermManageShows, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
 	// Retrieve all movies by their IDs
 	var movies []models.Movie
 	if err := initializers.Db.Where("id IN ?", body.MovieIDs).Find(&movies).Error; err != nil {
@@ -131,18 +364,26 @@ func AddMoviesInVenue(c *gin.Context) {
 func GetVenueByID(c *gin.Context) {
 	venueID := c.Param("id")
 	var venue models.Venue
-	if err := initializers.Db.Preload("Movies").Preload("ShowTimes").Preload("ShowTimes.Movie").First(&venue, venueID).Error; err != nil {
+	if err := initializers.Db.Preload("Movies").Preload("City.Region").Preload("Operator").Preload("Screens").Preload("ShowTimes").Preload("ShowTimes.Movie").First(&venue, venueID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"venue": venue,
-	})
+		// The venue's own policy, or its operator's when it has none
+		"cancellation_policy": venue.Policy(),
+	}
+	if venue.OperatingHours != nil {
+		response["open_now"] = venue.OperatingHours.IsOpenAt(time.Now().In(venue.TimeLocation()))
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 type ShowTimingsBody struct {
 	ShowTimings []string `json:"show_timings"`
 	MovieId     uint     `json:"movie_id"`
+	ScreenID    *uint    `json:"screen_id"` // Required once the venue has screens
+	Format      string   `json:"format"`    // Defaults to 2D
 }
 
 func AddShowTimings(c *gin.Context) {
@@ -156,37 +397,78 @@ func AddShowTimings(c *gin.Context) {
 	user, _ := c.Get("user")
 	//We get userDetails, because we need to check that we are admin or not
 	userDetails := user.(models.User)
-	if !userDetails.IsAdmin {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized, admin access required"})
-		return
-	}
 	//Check if venue exists
 	var venue models.Venue
-	if err := initializers.Db.First(&venue, venueID).Error; err != nil {
+	if err := initializers.Db.Preload("City.Region").Preload("Operator").First(&venue, venueID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
+	if !userDetails.CanForVenue(models.PermManageShows, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
 	// Validate the movie ID
 	var movie models.Movie
 	if err := initializers.Db.First(&movie, body.MovieId).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 		return
 	}
-	// Add show timings
+	screen, format, status, err := showScreen(venue.ID, body.ScreenID, body.Format)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	// Check every timing before saving any of them
+	type parsedTiming struct {
+		timing   string
+		startsAt *time.Time
+	}
+	timings := make([]parsedTiming, 0, len(body.ShowTimings))
 	for _, timingStr := range body.ShowTimings {
+		timing, startsAt, err := parseShowTiming(timingStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := venue.CheckShowTime(timing, startsAt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if screen != nil {
+			clash := screenBusy(screen.ID, timing, startsAt, 0)
+			for _, t := range timings {
+				clash = clash || (t.timing == timing && sameTime(t.startsAt, startsAt))
+			}
+			if clash {
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Screen %s already has a show at %s", screen.Name, timingStr)})
+				return
+			}
+		}
+		timings = append(timings, parsedTiming{timing, startsAt})
+	}
+	// Add show timings
+	for _, t := range timings {
+		timingStr := t.timing
 		// Create a new ShowTime record
 		showTime := models.ShowTime{
-			Timing:  timingStr,
-			MovieID: body.MovieId, // Associate with the movie
-			VenueID: venue.ID,     // Associate with the venue
+			Timing:   timingStr,
+			StartsAt: t.startsAt,
+			MovieID:  body.MovieId, // Associate with the movie
+			VenueID:  venue.ID,     // Associate with the venue
+			Format:   format,
+		}
+		capacity := 0
+		if screen != nil {
+			showTime.ScreenID = &screen.ID
+			capacity = screen.Capacity
 		}
 		// Save the show time record
 		if err := initializers.Db.Create(&showTime).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error saving show time for %s: %v", timingStr, err)})
 			return
 		}
-		// Generate the default seat layout for this showtime
-		seats := helpers.GenerateSeatsForShowTime(showTime.ID)
+		// Generate the screen's seat layout, or the default one, for this showtime
+		seats := helpers.GenerateSeatsForVenue(showTime.ID, capacity, venue)
 		// Save the generated seats to the database
 		if err := initializers.Db.Create(&seats).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error generating seats for %s: %v", timingStr, err)})
@@ -196,3 +478,437 @@ func AddShowTimings(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Show timings added successfully"})
 }
+
+// parseShowTiming accepts a daily "HH:MM" time or an RFC3339 timestamp for a show on a particular date
+func parseShowTiming(value string) (string, *time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return helpers.FormatShowTime(t), &t, nil
+	}
+	if _, err := time.Parse("15:04", value); err != nil {
+		return "", nil, fmt.Errorf("invalid timing %q, use HH:MM or an RFC3339 timestamp", value)
+	}
+	return value, nil, nil
+}
+
+// upcomingOrderCount counts confirmed orders for shows still ahead, narrowed by conditions on show_times.
+// Daily shows without a date are always ahead.
+func upcomingOrderCount(now time.Time, query string, args ...interface{}) int64 {
+	var count int64
+	initializers.Db.Model(&models.Order{}).
+		Joins("JOIN show_times ON show_times.id = orders.show_time_id AND show_times.deleted_at IS NULL").
+		Where("orders.status = ?", models.OrderConfirmed).
+		Where("(show_times.starts_at IS NULL OR show_times.starts_at > ?)", now).
+		Where(query, args...).
+		Count(&count)
+	return count
+}
+
+type UpdateVenueBody struct {
+	Name     *string `json:"name" validate:"omitempty,min=1"`
+	Location *string `json:"location" validate:"omitempty,min=1"`
+
+	AddressLine1 *string  `json:"address_line1"`
+	AddressLine2 *string  `json:"address_line2"`
+	Locality     *string  `json:"locality"`
+	PostalCode   *string  `json:"postal_code"`
+	CityID       *uint    `json:"city_id"`
+	Latitude     *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+
+	Amenities          *[]string                  `json:"amenities"`
+	CancellationPolicy *models.CancellationPolicy `json:"cancellation_policy"`
+	// Existing showtimes are kept when hours change; only new and moved shows are checked
+	OperatingHours *models.OperatingHours `json:"operating_hours"`
+	// Only a super admin can move a venue between operators; 0 makes it independent
+	OperatorID *uint `json:"operator_id"`
+}
+
+func UpdateVenue(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body UpdateVenueBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageVenues, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	if body.Name != nil {
+		venue.Name = *body.Name
+	}
+	if body.Location != nil {
+		venue.Location = *body.Location
+	}
+	for _, f := range []struct {
+		value *string
+		field *string
+	}{{body.AddressLine1, &venue.AddressLine1}, {body.AddressLine2, &venue.AddressLine2}, {body.Locality, &venue.Locality}, {body.PostalCode, &venue.PostalCode}} {
+		if f.value != nil {
+			*f.field = *f.value
+		}
+	}
+	if body.CityID != nil {
+		city, err := venueCity(body.CityID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+			return
+		}
+		venue.CityID = &city.ID
+		venue.City = city
+	}
+	if body.Latitude != nil {
+		venue.Latitude, venue.Longitude = body.Latitude, body.Longitude
+	}
+	if body.OperatorID != nil {
+		if !userDetails.CanGlobally(models.PermManageOperators) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only a super admin can change a venue's operator"})
+			return
+		}
+		if *body.OperatorID == 0 {
+			venue.OperatorID = nil
+		} else if err := initializers.Db.First(&models.Operator{}, *body.OperatorID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Operator not found"})
+			return
+		} else {
+			venue.OperatorID = body.OperatorID
+		}
+	}
+	if body.Amenities != nil {
+		amenities, err := canonicalTags(*body.Amenities, models.VenueAmenities, "amenity")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		venue.Amenities = amenities
+	}
+	if body.CancellationPolicy != nil {
+		venue.CancellationPolicy = body.CancellationPolicy
+	}
+	if body.OperatingHours != nil {
+		if err := body.OperatingHours.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		venue.OperatingHours = body.OperatingHours
+	}
+	if err := initializers.Db.Omit("City").Save(&venue).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update venue"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"venue": venue})
+}
+
+// DeleteVenue soft deletes the venue with its screens and showtimes. Venues with bookings for upcoming shows are kept,
+// their showtimes have to be cancelled first so customers are refunded.
+func DeleteVenue(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageVenues, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	if n := upcomingOrderCount(time.Now(), "show_times.venue_id = ?", venue.ID); n > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Venue has bookings for upcoming shows, cancel those showtimes first",
+			"upcoming_orders": n,
+		})
+		return
+	}
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("venue_id = ?", venue.ID).Delete(&models.ShowTime{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("venue_id = ?", venue.ID).Delete(&models.Screen{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&venue).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete venue"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Venue deleted"})
+}
+
+// RemoveMovieFromVenue undoes AddMoviesInVenue, taking the movie's showtimes at the venue with it
+func RemoveMovieFromVenue(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var venue models.Venue
+	if err := initializers.Db.First(&venue, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageShows, venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	var movie models.Movie
+	if err := initializers.Db.First(&movie, c.Param("movieId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return
+	}
+	if n := upcomingOrderCount(time.Now(), "show_times.venue_id = ? AND show_times.movie_id = ?", venue.ID, movie.ID); n > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Movie has bookings for upcoming shows at this venue, cancel those showtimes first",
+			"upcoming_orders": n,
+		})
+		return
+	}
+	var removed int64
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&venue).Association("Movies").Delete(&movie); err != nil {
+			return err
+		}
+		result := tx.Where("venue_id = ? AND movie_id = ?", venue.ID, movie.ID).Delete(&models.ShowTime{})
+		removed = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove movie from venue"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Movie removed from venue",
+		"showtimes_removed": removed,
+	})
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+type UpdateShowTimeBody struct {
+	Timing   *string `json:"timing"`
+	MovieID  *uint   `json:"movie_id"`
+	ScreenID *uint   `json:"screen_id"`
+	Format   *string `json:"format"`
+}
+
+// UpdateShowTime moves a showtime or changes its format. While nobody has booked it, its movie
+// can be swapped or it can move to another screen, which lays the seats out afresh.
+// Customers with bookings are told about the new time.
+func UpdateShowTime(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	var body UpdateShowTimeBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var showTime models.ShowTime
+	if err := initializers.Db.Preload("Movie").Preload("Venue.City.Region").Preload("Venue.Operator").Preload("Screen").
+		Where("venue_id = ?", c.Param("id")).First(&showTime, c.Param("showtimeId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ShowTime not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageShows, showTime.Venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	var orders []models.Order
+	initializers.Db.Where("show_time_id = ? AND status = ?", showTime.ID, models.OrderConfirmed).Find(&orders)
+
+	if body.MovieID != nil && *body.MovieID != showTime.MovieID {
+		if len(orders) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Showtime has bookings, cancel it instead of changing the movie"})
+			return
+		}
+		var movie models.Movie
+		if err := initializers.Db.First(&movie, *body.MovieID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		showTime.MovieID = movie.ID
+		showTime.Movie = movie
+	}
+	previous := ""
+	if body.Timing != nil {
+		timing, startsAt, err := parseShowTiming(*body.Timing)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if timing != showTime.Timing || !sameTime(startsAt, showTime.StartsAt) {
+			if err := showTime.Venue.CheckShowTime(timing, startsAt); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			previous = helpers.DescribeShow(showTime)
+			showTime.Timing = timing
+			showTime.StartsAt = startsAt
+		}
+	}
+	screenID, format := showTime.ScreenID, showTime.Format
+	if body.ScreenID != nil {
+		screenID = body.ScreenID
+	}
+	if body.Format != nil {
+		format = *body.Format
+	}
+	var screen *models.Screen
+	if screenID == nil {
+		// Showtimes from before the venue had screens stay unassigned until one is picked
+		canonical, ok := models.CanonicalFormat(format)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown format %q", format)})
+			return
+		}
+		format = canonical
+	} else {
+		var status int
+		var err error
+		if screen, format, status, err = showScreen(showTime.VenueID, screenID, format); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	showTime.Format = format
+	newScreen := screen != nil && (showTime.ScreenID == nil || *showTime.ScreenID != screen.ID)
+	if newScreen && len(orders) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Showtime has bookings, cancel it instead of changing the screen"})
+		return
+	}
+	if screen != nil {
+		if screenBusy(screen.ID, showTime.Timing, showTime.StartsAt, showTime.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Screen %s already has a show at %s", screen.Name, showTime.Timing)})
+			return
+		}
+		showTime.ScreenID = &screen.ID
+		showTime.Screen = screen
+	}
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Movie", "Venue", "Screen").Save(&showTime).Error; err != nil {
+			return err
+		}
+		if !newScreen {
+			return nil
+		}
+		// The new screen has its own layout, so the old seats go along with any holds on them
+		if err := tx.Where("show_time_id = ?", showTime.ID).Delete(&models.Seat{}).Error; err != nil {
+			return err
+		}
+		seats := helpers.GenerateSeatsForVenue(showTime.ID, screen.Capacity, showTime.Venue)
+		return tx.Create(&seats).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update showtime"})
+		return
+	}
+	if previous != "" && len(orders) > 0 {
+		go helpers.NotifyShowTimeChanged(showTime, orders, previous)
+	}
+	c.JSON(http.StatusOK, gin.H{"show_time": showTime})
+}
+
+type CancelShowTimeBody struct {
+	Reason string `json:"reason" validate:"max=200"`
+}
+
+// CancelShowTime calls off a show: every confirmed order is refunded in full, every seat is released
+// and the showtime is soft deleted so it can't be booked again. Refunds are recorded on the order.
+func CancelShowTime(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	// The reason is optional, so an empty body is fine
+	var body CancelShowTimeBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+			return
+		}
+	}
+	if body.Reason == "" {
+		body.Reason = "cancelled by the venue"
+	}
+	var showTime models.ShowTime
+	if err := initializers.Db.Preload("Movie").Preload("Venue").
+		Where("venue_id = ?", c.Param("id")).First(&showTime, c.Param("showtimeId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ShowTime not found"})
+		return
+	}
+	if !userDetails.CanForVenue(models.PermManageShows, showTime.Venue) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't manage this venue"})
+		return
+	}
+	now := time.Now()
+	// A show that has already played can't be called off and refunded
+	if !showTime.IsUpcoming(now) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This show has already started"})
+		return
+	}
+
+	var refunded []models.Order
+	var refundTotal float32
+	var released int64
+	err := initializers.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the seats so no reservation or booking slips in mid-cancellation
+		var seats []models.Seat
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("show_time_id = ?", showTime.ID).Find(&seats).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("show_time_id = ? AND status = ?", showTime.ID, models.OrderConfirmed).Find(&refunded).Error; err != nil {
+			return err
+		}
+		for i := range refunded {
+			refunded[i].Status = models.OrderRefunded
+			refunded[i].RefundAmount = refunded[i].TotalPrice
+			refunded[i].RefundedAt = &now
+			refunded[i].RefundReason = body.Reason
+			if err := tx.Omit("Seats").Save(&refunded[i]).Error; err != nil {
+				return err
+			}
+			refundTotal += refunded[i].RefundAmount
+		}
+		result := tx.Model(&models.Seat{}).
+			Where("show_time_id = ? AND (is_booked = ? OR is_reserved = ?)", showTime.ID, true, true).
+			Updates(map[string]interface{}{
+				"is_booked":           false,
+				"is_reserved":         false,
+				"is_available":        true,
+				"reserved_by_user_id": nil,
+				"reserved_at":         nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		released = result.RowsAffected
+		return tx.Delete(&models.ShowTime{}, showTime.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel showtime"})
+		return
+	}
+	if len(refunded) > 0 {
+		go helpers.NotifyShowTimeCancelled(showTime, refunded, body.Reason)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Showtime cancelled",
+		"refunded_orders": len(refunded),
+		"refunded_amount": refundTotal,
+		"released_seats":  released,
+	})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	// How long a user waits before another verification email can be sent
+	verificationResendInterval = 2 * time.Minute
+)
+
+// verificationWait is how long until another verification email may be sent to the user, or zero
+func verificationWait(user models.User) time.Duration {
+	if user.VerificationSentAt == nil {
+		return 0
+	}
+	return max(time.Until(user.VerificationSentAt.Add(verificationResendInterval)), 0)
+}
+
+// sendVerificationEmail emails the user a signed link to confirm their address
+func sendVerificationEmail(user *models.User) error {
+	token, err := helpers.SignEmailVerification(user.ID, user.Email, time.Now().Add(emailVerificationTTL))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := initializers.Db.Model(user).Update("verification_sent_at", now).Error; err != nil {
+		return err
+	}
+	link := helpers.FrontendLink("/verify-email", url.Values{"token": {token}})
+	return helpers.NotifySecret(*user, "Confirm your email",
+		"Welcome to BookMyShow! Confirm your email within 24 hours to start booking: "+link, token)
+}
+
+// sendEmailChangeVerification emails a link to the address the user wants to change to. Their email
+// stays as it is until they follow it.
+func sendEmailChangeVerification(user *models.User) error {
+	token, err := helpers.SignEmailVerification(user.ID, user.PendingEmail, time.Now().Add(emailVerificationTTL))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := initializers.Db.Model(user).Update("verification_sent_at", now).Error; err != nil {
+		return err
+	}
+	recipient := *user
+	recipient.Email = user.PendingEmail
+	link := helpers.FrontendLink("/verify-email", url.Values{"token": {token}})
+	return helpers.NotifySecret(recipient, "Confirm your new email",
+		"Confirm this is your new email address within 24 hours: "+link+"\nUntil then your account keeps using "+user.Email+".", token)
+}
+
+// VerifyEmail marks the user's email verified with the token from their verification link. A link sent
+// for an email change switches the account to the new address.
+func VerifyEmail(c *gin.Context) {
+	var body struct {
+		Token string `json:"token" validate:"required"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+		return
+	}
+	userID, email, err := helpers.ParseEmailVerification(body.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid or has expired"})
+		return
+	}
+	var user models.User
+	if err := initializers.Db.First(&user, userID).Error; err != nil || (user.Email != email && user.PendingEmail != email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid or has expired"})
+		return
+	}
+	if user.Email != email {
+		var taken int64
+		initializers.Db.Model(&models.User{}).Where("email = ? AND id <> ?", email, user.ID).Count(&taken)
+		if taken > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+			return
+		}
+		previous := user
+		now := time.Now()
+		err := initializers.Db.Model(&user).Updates(map[string]interface{}{"email": email, "pending_email": "", "email_verified_at": now}).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+			return
+		}
+		user.Email, user.PendingEmail, user.EmailVerifiedAt = email, "", &now
+		// The old address hears about it too, in case someone else made the change
+		helpers.Notify(previous, "Your email was changed", "The email on your account was changed to "+email+". If this wasn't you, contact support.")
+		c.JSON(http.StatusOK, gin.H{"message": "Email changed", "user": user})
+		return
+	}
+	if user.EmailVerifiedAt == nil {
+		if err := initializers.Db.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "user": user})
+}
+
+// ResendVerification sends another verification email, to the new address during an email change,
+// at most once every couple of minutes
+func ResendVerification(c *gin.Context) {
+	user, _ := c.Get("user")
+	userDetails := user.(models.User)
+	if userDetails.EmailVerifiedAt != nil && userDetails.PendingEmail == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+	if wait := verificationWait(userDetails); wait > 0 {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "A verification email was sent recently, please check your inbox",
+			"retry_after": int(wait.Seconds()) + 1,
+		})
+		return
+	}
+	send := sendVerificationEmail
+	if userDetails.PendingEmail != "" {
+		send = sendEmailChangeVerification
+	}
+	if err := send(&userDetails); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
@@ -8,6 +8,21 @@ const api = axios.create({
   headers: { 'Content-Type': 'application/json' },
 })
 
+// Access tokens are short-lived: on a 401, swap the refresh cookie for new tokens once and retry
+api.interceptors.response.use(undefined, async (error) => {
+  const config = error.config
+  if (error.response?.status !== 401 || !config || config._retried || config.url.startsWith('/user/token') || config.url === '/user/login') {
+    return Promise.reject(error)
+  }
+  config._retried = true
+  try {
+    await api.post('/user/token/refresh')
+  } catch (e) {
+    return Promise.reject(error)
+  }
+  return api(config)
+})
+
 // Auth
 export const login = (data) => api.post('/user/login', data)
 export const getMe = () => api.get('/user/me')
@@ -10,6 +10,8 @@ export default defineConfig({
         target: 'http://localhost:8080',
         changeOrigin: true,
         rewrite: (path) => path.replace(/^\/api/, ''),
+        // The refresh cookie is scoped to /user/token on the API
+        cookiePathRewrite: { '/user/token': '/api/user/token' },
       },
     },
   },
@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+)
+
+const defaultDeletionCoolingOff = 14 * 24 * time.Hour
+
+// AccountDeletionCoolingOff is how long a user has to change their mind after asking to delete their account,
+// ACCOUNT_DELETION_COOLING_OFF or 14 days
+func AccountDeletionCoolingOff() time.Duration {
+	return durationEnv("ACCOUNT_DELETION_COOLING_OFF", defaultDeletionCoolingOff)
+}
+
+// AnonymizeAccount scrubs the user's personal data and deletes what only matters to them: logins, linked
+// providers, roles, reviews and votes, interests, notifications and held seats. Orders stay as they are for
+// accounting, pointing at the anonymized user.
+func AnonymizeAccount(user models.User) error {
+	now := time.Now()
+	phone := user.PhoneNumber
+	user.Anonymize(now)
+	return initializers.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user).Select("name", "email", "password", "no_password", "phone_number", "phone_verified_at",
+			"preferred_city_id", "preferences", "email_verified_at", "verification_sent_at", "pending_email",
+			"totp_secret", "totp_enabled_at", "totp_last_step", "recovery_codes", "two_factor_failures",
+			"two_factor_locked_until", "disabled_reason", "deletion_scheduled_at", "anonymized_at").Updates(&user).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.Session{}, &models.RefreshToken{}, &models.PasswordReset{}, &models.OTPChallenge{},
+			&models.Identity{}, &models.OIDCLogin{}, &models.RoleAssignment{}, &models.MovieInterest{}, &models.Notification{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if phone != "" {
+			if err := tx.Unscoped().Where("phone = ?", phone).Delete(&models.OTPChallenge{}).Error; err != nil {
+				return err
+			}
+		}
+		// Their votes come off the reviews they found helpful before going
+		err = tx.Model(&models.Review{}).
+			Where("id IN (?)", tx.Model(&models.ReviewVote{}).Select("review_id").Where("user_id = ?", user.ID)).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count - 1")).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.Seat{}).Where("reserved_by_user_id = ? AND NOT is_booked", user.ID).
+			Updates(map[string]interface{}{"is_reserved": false, "is_available": true, "reserved_by_user_id": nil, "reserved_at": nil}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&models.AuditLog{Action: models.AuditUserAnonymized, TargetUserID: user.ID}).Error; err != nil {
+			return err
+		}
+		// Soft deleted so the account drops out of lookups, while orders can still load it unscoped
+		return tx.Delete(&user).Error
+	})
+}
+
+// DeleteDueAccounts anonymizes the accounts whose cooling-off period has run out
+func DeleteDueAccounts() {
+	var users []models.User
+	initializers.Db.Where("deletion_scheduled_at < ? AND anonymized_at IS NULL", time.Now()).Find(&users)
+	for _, user := range users {
+		if err := AnonymizeAccount(user); err != nil {
+			log.Printf("[account] failed to delete user %d: %v\n", user.ID, err)
+		}
+	}
+}
+
+// StartAccountDeleter runs DeleteDueAccounts every interval
+func StartAccountDeleter(interval time.Duration) {
+	for {
+		DeleteDueAccounts()
+		time.Sleep(interval)
+	}
+}
+
+// WriteExportArchive writes a zip with one indented JSON file per entry, named after its key
+func WriteExportArchive(w io.Writer, files map[string]interface{}) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := archive.Create(name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
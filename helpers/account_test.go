@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteExportArchive(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteExportArchive(&buf, map[string]interface{}{
+		"profile": map[string]string{"name": "Asha"},
+		"orders":  []int{1, 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.File) != 2 || archive.File[0].Name != "orders.json" || archive.File[1].Name != "profile.json" {
+		t.Fatalf("unexpected files %v", archive.File)
+	}
+	f, err := archive.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var profile map[string]string
+	if err := json.NewDecoder(f).Decode(&profile); err != nil || profile["name"] != "Asha" {
+		t.Errorf("profile not round-tripped: %v %v", profile, err)
+	}
+}
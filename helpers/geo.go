@@ -0,0 +1,34 @@
+package helpers
+
+import "math"
+
+// Mean radius of the earth, which is close enough for finding cinemas nearby
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the great-circle distance between two points given in degrees, using the haversine formula
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	rad1, rad2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad1)*math.Cos(rad2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns the latitude and longitude ranges that contain every point within radiusKm.
+// It is a cheap prefilter for an indexed query; DistanceKm gives the exact answer.
+// Near the poles, or across the antimeridian, every longitude is returned.
+func BoundingBox(lat, lng, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat = math.Max(-90, lat-dLat), math.Min(90, lat+dLat)
+	if minLat == -90 || maxLat == 90 {
+		return minLat, maxLat, -180, 180
+	}
+	// A degree of longitude gets shorter away from the equator, so use the widest latitude in the box
+	widest := math.Max(math.Abs(minLat), math.Abs(maxLat)) * math.Pi / 180
+	dLng := dLat / math.Cos(widest)
+	minLng, maxLng = lng-dLng, lng+dLng
+	if minLng < -180 || maxLng > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLng, maxLng
+}
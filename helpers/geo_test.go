@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	cases := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		expected               float64
+	}{
+		{"same point", 19.076, 72.8777, 19.076, 72.8777, 0},
+		{"Mumbai to Delhi", 19.076, 72.8777, 28.6139, 77.209, 1148},
+		{"one degree of latitude", 0, 0, 1, 0, 111.19},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.19},
+	}
+	for _, tc := range cases {
+		got := DistanceKm(tc.lat1, tc.lng1, tc.lat2, tc.lng2)
+		if math.Abs(got-tc.expected) > 1 {
+			t.Errorf("%s: expected about %.2f km, got %.2f", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestBoundingBox_ContainsRadius(t *testing.T) {
+	lat, lng, radius := 60.0, 10.0, 50.0
+	minLat, maxLat, minLng, maxLng := BoundingBox(lat, lng, radius)
+	// Points due north, south, east and west at the edge of the radius must be inside the box
+	for _, bearing := range []float64{0, 90, 180, 270} {
+		b := bearing * math.Pi / 180
+		d := radius / earthRadiusKm
+		rad := lat * math.Pi / 180
+		pLat := math.Asin(math.Sin(rad)*math.Cos(d) + math.Cos(rad)*math.Sin(d)*math.Cos(b))
+		pLng := lng*math.Pi/180 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(rad), math.Cos(d)-math.Sin(rad)*math.Sin(pLat))
+		pLatDeg, pLngDeg := pLat*180/math.Pi, pLng*180/math.Pi
+		if pLatDeg < minLat-1e-9 || pLatDeg > maxLat+1e-9 || pLngDeg < minLng-1e-9 || pLngDeg > maxLng+1e-9 {
+			t.Errorf("bearing %.0f: point %.4f,%.4f is outside the box", bearing, pLatDeg, pLngDeg)
+		}
+	}
+	if _, _, minLng, maxLng := BoundingBox(0, 179.9, 50); minLng != -180 || maxLng != 180 {
+		t.Errorf("expected every longitude across the antimeridian, got %.2f..%.2f", minLng, maxLng)
+	}
+}
@@ -1,19 +1,14 @@
 package helpers
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"os"
+	"log"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
-	"github.com/twilio/twilio-go"
-	openapi "github.com/twilio/twilio-go/rest/verify/v2"
 )
 
 func FormatShowTime(t time.Time) string {
@@ -24,21 +19,41 @@ func FormatShowTime(t time.Time) string {
 // GenerateSeatsForShowTime generates the default seat layout for a showtime
 func GenerateSeatsForShowTime(showtimeID uint) []models.Seat {
 	// Define the default seat layout (e.g., 5 rows with 10 seats each)
-	rows := []string{"A", "B", "C", "D", "E"} // Example rows
-	seatsPerRow := 10                         // Example: 10 seats per row
+	return GenerateSeatsForCapacity(showtimeID, 0)
+}
+
+// GenerateSeatsForCapacity lays out exactly capacity seats in rows A-Z, at least 10 to a row.
+// The last row may be short. A capacity of 0 gives the default 5x10 layout.
+func GenerateSeatsForCapacity(showtimeID uint, capacity int) []models.Seat {
+	if capacity <= 0 {
+		capacity = 50
+	}
+	// Rows are a single letter, so a big screen gets wider rows rather than more of them
+	seatsPerRow := max(10, (capacity+25)/26)
 
 	var seats []models.Seat
-	for _, row := range rows {
-		for seatNumber := 1; seatNumber <= seatsPerRow; seatNumber++ {
-			seat := models.Seat{
-				SeatNumber:  fmt.Sprintf("%s%d", row, seatNumber), // e.g., A1, A2, B1, etc.
-				IsAvailable: true,                                 // All seats are available initially
-				IsReserved:  false,
-				IsBooked:    false,
-				Price:       250,
-				ShowTimeID:  showtimeID,
-			}
-			seats = append(seats, seat)
+	for i := 0; i < capacity; i++ {
+		row := string(rune('A' + i/seatsPerRow))
+		seat := models.Seat{
+			SeatNumber:  fmt.Sprintf("%s%d", row, i%seatsPerRow+1), // e.g., A1, A2, B1, etc.
+			IsAvailable: true,                                      // All seats are available initially
+			IsReserved:  false,
+			IsBooked:    false,
+			Price:       250,
+			ShowTimeID:  showtimeID,
+		}
+		seats = append(seats, seat)
+	}
+	return seats
+}
+
+// GenerateSeatsForVenue is GenerateSeatsForCapacity priced from the venue's operator defaults.
+// The venue's operator must be loaded.
+func GenerateSeatsForVenue(showtimeID uint, capacity int, venue models.Venue) []models.Seat {
+	seats := GenerateSeatsForCapacity(showtimeID, capacity)
+	if price := venue.SeatPrice(); price > 0 {
+		for i := range seats {
+			seats[i].Price = price
 		}
 	}
 	return seats
@@ -110,79 +125,16 @@ func UnReserveSeats(seatIDs []uint, duration time.Duration) {
 	tx.Commit()
 }
 
-func SendOtp(phone string) (string, error) {
-
-	accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	serviceId := os.Getenv("TWILIO_SERVICE_SID")
-
-	var client *twilio.RestClient = twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: accountSID,
-		Password: authToken,
-	})
-
-	to := "+91" + phone
-	params := &openapi.CreateVerificationParams{}
-	params.SetTo(to)
-	params.SetChannel("sms")
-
-	resp, err := client.VerifyV2.CreateVerification(serviceId, params)
-	if err != nil {
-		fmt.Println(err.Error())
-		return "", errors.New("otp failed to generate")
-	} else {
-		fmt.Printf("Sent verification '%s'\n", *resp.Sid)
-		return *resp.Sid, nil
-	}
+// SaveFile stores an upload in the configured blob store and returns its public URL
+func SaveFile(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	return initializers.Blobs.Put(ctx, key, body, contentType)
 }
 
-func CheckOtp(phone, code string) error {
-
-	accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	serviceId := os.Getenv("TWILIO_SERVICE_SID")
-
-	var client *twilio.RestClient = twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: accountSID,
-		Password: authToken,
-	})
-
-	to := "+91" + phone
-	params := &openapi.CreateVerificationCheckParams{}
-	params.SetTo(to)
-	params.SetCode(code)
-
-	resp, err := client.VerifyV2.CreateVerificationCheck(serviceId, params)
-
-	if err != nil {
-		fmt.Println(err.Error())
-		return errors.New("invalid otp")
-	} else if *resp.Status == "approved" {
-		return nil
-	} else {
-		return errors.New("invalid otp")
-	}
-}
-
-// Function to save file to aws
-func SaveFile(fileReader io.Reader, fileHeader *multipart.FileHeader) (string, error) {
-
-	bucketName := os.Getenv("AWS_BUCKET_NAME")
-
-	// Use unique key to avoid overwriting files with same name
-	uniqueKey := fmt.Sprintf("%d_%s", time.Now().UnixNano(), fileHeader.Filename)
-
-	_, err := initializers.Uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(uniqueKey),
-		Body:   fileReader,
-	})
-	if err != nil {
-		return "", err
+// DeleteFiles removes stored files, logging rather than failing so a stray file never blocks a request
+func DeleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := initializers.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("[storage] failed to delete %s: %v\n", key, err)
+		}
 	}
-
-	// Get the URL of the uploaded file (key may need encoding for special chars)
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucketName, uniqueKey)
-
-	return url, nil
 }
@@ -38,6 +38,40 @@ func TestGenerateSeatsForShowTime(t *testing.T) {
 	}
 }
 
+func TestGenerateSeatsForCapacity(t *testing.T) {
+	cases := []struct {
+		capacity    int
+		first, last string
+	}{
+		{capacity: 25, first: "A1", last: "C5"},    // A short last row
+		{capacity: 120, first: "A1", last: "L10"},  // 10 to a row
+		{capacity: 520, first: "A1", last: "Z20"},  // Wider rows once A-Z is full
+		{capacity: 1000, first: "A1", last: "Z25"}, // 39 to a row
+	}
+	for _, tc := range cases {
+		seats := GenerateSeatsForCapacity(7, tc.capacity)
+		if len(seats) != tc.capacity {
+			t.Errorf("capacity %d: expected %d seats, got %d", tc.capacity, tc.capacity, len(seats))
+			continue
+		}
+		if seats[0].SeatNumber != tc.first || seats[len(seats)-1].SeatNumber != tc.last {
+			t.Errorf("capacity %d: expected %s..%s, got %s..%s", tc.capacity, tc.first, tc.last, seats[0].SeatNumber, seats[len(seats)-1].SeatNumber)
+		}
+		// Every row has to be one letter for the seat matrix
+		matrix := CreateSeatMatrix(seats)
+		total := 0
+		for _, row := range matrix {
+			total += len(row)
+		}
+		if total != tc.capacity {
+			t.Errorf("capacity %d: seat matrix holds %d seats", tc.capacity, total)
+		}
+	}
+	if len(GenerateSeatsForCapacity(1, 0)) != 50 {
+		t.Error("no capacity should give the default layout")
+	}
+}
+
 func TestCreateSeatMatrix(t *testing.T) {
 	seats := []models.Seat{
 		{SeatNumber: "A1", IsAvailable: true, IsReserved: false, IsBooked: false, Price: 250},
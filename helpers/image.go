@@ -0,0 +1,223 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// Limits on uploaded images, checked before anything is decoded
+const (
+	MaxImageBytes     = 10 << 20 // 10MB
+	MaxImageDimension = 8000     // Longest side in pixels
+	MaxImagePixels    = 40_000_000
+)
+
+// Output formats for processed images
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatWebP = "webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type, upload a JPEG, PNG or GIF")
+	ErrImageTooLarge    = fmt.Errorf("image is too large, the limit is %dMB and %dpx per side", MaxImageBytes>>20, MaxImageDimension)
+)
+
+// ImageVariant is one standard size an upload is re-encoded to
+type ImageVariant struct {
+	Name  string
+	Width int
+}
+
+// Poster sizes, portrait
+var PosterVariants = []ImageVariant{
+	{Name: "thumbnail", Width: 185},
+	{Name: "card", Width: 500},
+	{Name: "hero", Width: 1280},
+}
+
+// Backdrop and still sizes, landscape
+var BackdropVariants = []ImageVariant{
+	{Name: "thumbnail", Width: 300},
+	{Name: "card", Width: 780},
+	{Name: "hero", Width: 1920},
+}
+
+// VariantsFor returns the sizes used for a kind of movie media
+func VariantsFor(mediaType string) []ImageVariant {
+	if mediaType == models.MediaPoster {
+		return PosterVariants
+	}
+	return BackdropVariants
+}
+
+// ProcessedImage is one encoded variant of an upload
+type ProcessedImage struct {
+	Variant     string
+	Format      string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Extension returns the file extension for the image's format
+func (p ProcessedImage) Extension() string {
+	if p.Format == ImageFormatWebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// DecodeImage checks that data really is a JPEG, PNG or GIF within the size limits and decodes it.
+// The type comes from the bytes themselves, never from the file name or the client's content type.
+func DecodeImage(data []byte) (image.Image, error) {
+	if len(data) > MaxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+	var decodeConfig func([]byte) (image.Config, error)
+	var decode func([]byte) (image.Image, error)
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case "image/png":
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case "image/gif":
+		// Animated GIFs keep their first frame
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, ErrUnsupportedImage
+	}
+
+	// Check the header first so a tiny file claiming huge dimensions is never decoded
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width < 1 || config.Height < 1 {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width > MaxImageDimension || config.Height > MaxImageDimension || config.Width*config.Height > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	img, err := decode(data)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return img, nil
+}
+
+// ProcessImage validates an upload and re-encodes it to every variant as both JPEG and WebP.
+// Only pixels are carried over, so EXIF, GPS and other metadata in the upload is dropped.
+// Images are never scaled up; a variant wider than the upload keeps the upload's size.
+func ProcessImage(data []byte, variants []ImageVariant) ([]ProcessedImage, error) {
+	src, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	var processed []ProcessedImage
+	for _, variant := range variants {
+		resized := ResizeToWidth(src, variant.Width)
+		bounds := resized.Bounds()
+
+		// JPEG has no alpha, so transparent areas go on white rather than black
+		flat := image.NewRGBA(bounds)
+		draw.Draw(flat, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, bounds, resized, bounds.Min, draw.Over)
+		var jpegBuf bytes.Buffer
+		if err := jpeg.Encode(&jpegBuf, flat, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		var webpBuf bytes.Buffer
+		if err := EncodeWebP(&webpBuf, resized); err != nil {
+			return nil, err
+		}
+
+		processed = append(processed,
+			ProcessedImage{Variant: variant.Name, Format: ImageFormatJPEG, Width: bounds.Dx(), Height: bounds.Dy(), ContentType: "image/jpeg", Data: jpegBuf.Bytes()},
+			ProcessedImage{Variant: variant.Name, Format: ImageFormatWebP, Width: bounds.Dx(), Height: bounds.Dy(), ContentType: "image/webp", Data: webpBuf.Bytes()},
+		)
+	}
+	return processed, nil
+}
+
+// ResizeToWidth scales src down to the given width, keeping its aspect ratio.
+// Each output pixel is the area-weighted average of the source pixels it covers.
+func ResizeToWidth(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	// Work on premultiplied RGBA so transparent pixels don't bleed colour into their neighbours
+	rgba := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if width >= srcW {
+		return rgba
+	}
+	height := max(1, (srcH*width+srcW/2)/srcW)
+
+	// Resize rows first, then columns
+	cols := boxWeights(srcW, width)
+	rows := boxWeights(srcH, height)
+	horizontal := make([]float64, width*srcH*4)
+	for y := 0; y < srcH; y++ {
+		for x, taps := range cols {
+			var sum [4]float64
+			for _, tap := range taps {
+				p := rgba.Pix[y*rgba.Stride+tap.index*4:]
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += float64(p[ch]) * tap.weight
+				}
+			}
+			copy(horizontal[(y*width+x)*4:], sum[:])
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, taps := range rows {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for _, tap := range taps {
+				p := horizontal[(tap.index*width+x)*4:]
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += p[ch] * tap.weight
+				}
+			}
+			for ch := 0; ch < 4; ch++ {
+				dst.Pix[y*dst.Stride+x*4+ch] = uint8(min(255, sum[ch]+0.5))
+			}
+		}
+	}
+	return dst
+}
+
+type boxTap struct {
+	index  int
+	weight float64
+}
+
+// boxWeights works out, for each output pixel along one axis, which source pixels it covers and by how much
+func boxWeights(srcSize, dstSize int) [][]boxTap {
+	scale := float64(srcSize) / float64(dstSize)
+	weights := make([][]boxTap, dstSize)
+	for i := range weights {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < srcSize && float64(j) < end; j++ {
+			overlap := min(end, float64(j+1)) - max(start, float64(j))
+			if overlap > 0 {
+				weights[i] = append(weights[i], boxTap{index: j, weight: overlap / scale})
+			}
+		}
+	}
+	return weights
+}
@@ -0,0 +1,198 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x + y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeImage_RejectsNonImages(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"text":      []byte("definitely not an image"),
+		"html":      []byte("<html><body>poster.png</body></html>"),
+		"truncated": encodePNG(t, 10, 10)[:30],
+	} {
+		if _, err := DecodeImage(data); !errors.Is(err, ErrUnsupportedImage) {
+			t.Errorf("%s: expected ErrUnsupportedImage, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeImage_RejectsOversizedDimensions(t *testing.T) {
+	if _, err := DecodeImage(encodePNG(t, MaxImageDimension+1, 2)); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge for a wide image, got %v", err)
+	}
+
+	// A header claiming huge dimensions is rejected without decoding the pixels
+	data := encodePNG(t, 10, 10)
+	binary.BigEndian.PutUint32(data[16:20], 7000)
+	binary.BigEndian.PutUint32(data[20:24], 7000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := DecodeImage(data); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge for too many pixels, got %v", err)
+	}
+}
+
+func TestDecodeImage_RejectsOversizedBytes(t *testing.T) {
+	data := append(encodePNG(t, 10, 10), make([]byte, MaxImageBytes)...)
+	if _, err := DecodeImage(data); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+func TestProcessImage_Variants(t *testing.T) {
+	data := encodePNG(t, 600, 900)
+	src, err := DecodeImage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed, err := ProcessImage(data, PosterVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processed) != len(PosterVariants)*2 {
+		t.Fatalf("expected a JPEG and a WebP per variant, got %d images", len(processed))
+	}
+	expected := map[string][2]int{
+		"thumbnail": {185, 278},
+		"card":      {500, 750},
+		"hero":      {600, 900}, // Never scaled up
+	}
+	for _, p := range processed {
+		size := expected[p.Variant]
+		if p.Width != size[0] || p.Height != size[1] {
+			t.Errorf("%s %s: expected %dx%d, got %dx%d", p.Variant, p.Format, size[0], size[1], p.Width, p.Height)
+		}
+		switch p.Format {
+		case ImageFormatJPEG:
+			img, err := jpeg.Decode(bytes.NewReader(p.Data))
+			if err != nil {
+				t.Errorf("%s: invalid JPEG: %v", p.Variant, err)
+				continue
+			}
+			if img.Bounds().Dx() != p.Width || img.Bounds().Dy() != p.Height {
+				t.Errorf("%s: JPEG is %v, expected %dx%d", p.Variant, img.Bounds(), p.Width, p.Height)
+			}
+		case ImageFormatWebP:
+			if string(p.Data[0:4]) != "RIFF" || string(p.Data[8:16]) != "WEBPVP8L" {
+				t.Errorf("%s: not a lossless WebP file", p.Variant)
+			}
+			if int(binary.LittleEndian.Uint32(p.Data[4:8]))+8 != len(p.Data) {
+				t.Errorf("%s: RIFF size does not match the file", p.Variant)
+			}
+			img, err := webp.Decode(bytes.NewReader(p.Data))
+			if err != nil {
+				t.Errorf("%s: invalid WebP: %v", p.Variant, err)
+				continue
+			}
+			if img.Bounds().Dx() != p.Width || img.Bounds().Dy() != p.Height {
+				t.Errorf("%s: WebP is %v, expected %dx%d", p.Variant, img.Bounds(), p.Width, p.Height)
+				continue
+			}
+			// Lossless, so it decodes to exactly the resized pixels
+			want := ResizeToWidth(src, p.Width)
+			for _, pt := range []image.Point{{0, 0}, {p.Width - 1, 0}, {p.Width / 2, p.Height / 2}, {p.Width - 1, p.Height - 1}} {
+				got := color.NRGBAModel.Convert(img.At(pt.X, pt.Y))
+				if expected := color.NRGBAModel.Convert(want.At(pt.X, pt.Y)); got != expected {
+					t.Errorf("%s: WebP pixel at %v is %v, expected %v", p.Variant, pt, got, expected)
+				}
+			}
+		default:
+			t.Errorf("unexpected format %q", p.Format)
+		}
+	}
+}
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	// Noisy, partly transparent pixels, at a size that isn't a multiple of the predictor blocks
+	src := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	seed := uint32(1)
+	for i := range src.Pix {
+		seed = seed*1664525 + 1013904223
+		src.Pix[i] = uint8(seed >> 24)
+	}
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	img, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("invalid WebP: %v", err)
+	}
+	if img.Bounds() != src.Bounds() {
+		t.Fatalf("WebP is %v, expected %v", img.Bounds(), src.Bounds())
+	}
+	for y := 0; y < 23; y++ {
+		for x := 0; x < 37; x++ {
+			if got, expected := color.NRGBAModel.Convert(img.At(x, y)), src.NRGBAAt(x, y); got != expected {
+				t.Fatalf("pixel at %d,%d is %v, expected %v", x, y, got, expected)
+			}
+		}
+	}
+}
+
+func TestProcessImage_StripsMetadata(t *testing.T) {
+	// A JPEG with an EXIF segment carrying a marker string
+	var src bytes.Buffer
+	if err := jpeg.Encode(&src, image.NewGray(image.Rect(0, 0, 300, 300)), nil); err != nil {
+		t.Fatal(err)
+	}
+	marker := []byte("Exif\x00\x00GPS-SECRET-LOCATION")
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(marker)+2))
+	data := append(append(append([]byte{}, src.Bytes()[:2]...), append(segment, marker...)...), src.Bytes()[2:]...)
+
+	processed, err := ProcessImage(data, PosterVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range processed {
+		if bytes.Contains(p.Data, []byte("GPS-SECRET-LOCATION")) {
+			t.Errorf("%s %s still carries the upload's metadata", p.Variant, p.Format)
+		}
+	}
+}
+
+func TestResizeToWidth_AveragesArea(t *testing.T) {
+	// Alternating black and white columns average to mid grey
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 1 {
+				src.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	dst := ResizeToWidth(src, 2)
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 1 {
+		t.Fatalf("expected 2x1, got %v", dst.Bounds())
+	}
+	for x := 0; x < 2; x++ {
+		if r, _, _, _ := dst.At(x, 0).RGBA(); r>>8 < 127 || r>>8 > 128 {
+			t.Errorf("pixel %d: expected mid grey, got %d", x, r>>8)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// Notify records a notification for the user on each of their channels (see User.Channels) and hands it
+// to delivery. There is no mail, SMS or push provider wired up yet, so delivery is a log line.
+func Notify(user models.User, subject, body string) error {
+	for _, channel := range user.Channels() {
+		if err := notify(user, channel, subject, body, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifySecret is Notify for a message carrying a one-time secret such as a reset link. It only goes by
+// email, whatever the user's channels, and the copy kept in notifications has the secret blanked out.
+func NotifySecret(user models.User, subject, body, secret string) error {
+	return notify(user, models.ChannelEmail, subject, strings.ReplaceAll(body, secret, "[redacted]"), body)
+}
+
+func notify(user models.User, channel, subject, recorded, delivered string) error {
+	sentAt := time.Now()
+	notification := models.Notification{
+		UserID:  user.ID,
+		Channel: channel,
+		Subject: subject,
+		Body:    recorded,
+		SentAt:  &sentAt,
+	}
+	if err := initializers.Db.Create(&notification).Error; err != nil {
+		return err
+	}
+	to := user.Email
+	switch channel {
+	case models.ChannelSMS:
+		to = user.PhoneNumber
+	case models.ChannelPush:
+		to = fmt.Sprintf("user:%d", user.ID)
+	}
+	log.Printf("[notify] channel=%s to=%s subject=%q body=%q\n", channel, to, subject, delivered)
+	return nil
+}
+
+// NotifyBookingOpened tells every interested user whose movie has opened for booking
+func NotifyBookingOpened() {
+	now := time.Now()
+	var interests []models.MovieInterest
+	initializers.Db.Where("notified_at IS NULL").Find(&interests)
+
+	for _, interest := range interests {
+		var movie models.Movie
+		if err := initializers.Db.First(&movie, interest.MovieID).Error; err != nil {
+			continue
+		}
+		if !movie.IsBookable(now) {
+			continue
+		}
+		var user models.User
+		if err := initializers.Db.First(&user, interest.UserID).Error; err != nil {
+			continue
+		}
+		// Claiming the interest first means a sweep running alongside this one doesn't notify the user again
+		claim := initializers.Db.Model(&models.MovieInterest{}).Where("id = ? AND notified_at IS NULL", interest.ID).Update("notified_at", now)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		subject := fmt.Sprintf("Bookings are open for %s", movie.Title)
+		body := fmt.Sprintf("You asked us to let you know - tickets for %s can now be booked.", movie.Title)
+		if err := Notify(user, subject, body); err != nil {
+			// Left for the next sweep to try again
+			initializers.Db.Model(&models.MovieInterest{}).Where("id = ?", interest.ID).Update("notified_at", nil)
+		}
+	}
+}
+
+// DescribeShow names a showtime in notifications, e.g. "Inception at PVR Cinemas, 18:00"
+func DescribeShow(showTime models.ShowTime) string {
+	when := showTime.Timing
+	if showTime.StartsAt != nil {
+		when = showTime.StartsAt.Format("Mon 2 Jan 2006, 15:04")
+	}
+	return fmt.Sprintf("%s at %s, %s", showTime.Movie.Title, showTime.Venue.Name, when)
+}
+
+// NotifyShowTimeCancelled tells each refunded customer their show is off.
+// The showtime should have its Movie and Venue loaded.
+func NotifyShowTimeCancelled(showTime models.ShowTime, orders []models.Order, reason string) {
+	for _, order := range orders {
+		var user models.User
+		if err := initializers.Db.First(&user, order.UserID).Error; err != nil {
+			continue
+		}
+		subject := fmt.Sprintf("Cancelled: %s", showTime.Movie.Title)
+		body := fmt.Sprintf("Your booking for %s has been cancelled (%s). A refund of %.2f has been issued for order #%d.",
+			DescribeShow(showTime), reason, order.RefundAmount, order.ID)
+		Notify(user, subject, body)
+	}
+}
+
+// NotifyShowTimeChanged tells customers with bookings that their show has moved.
+// The showtime should have its Movie and Venue loaded.
+func NotifyShowTimeChanged(showTime models.ShowTime, orders []models.Order, previous string) {
+	for _, order := range orders {
+		var user models.User
+		if err := initializers.Db.First(&user, order.UserID).Error; err != nil {
+			continue
+		}
+		subject := fmt.Sprintf("Showtime changed: %s", showTime.Movie.Title)
+		body := fmt.Sprintf("Your booking (order #%d) was for %s. The show is now %s.", order.ID, previous, DescribeShow(showTime))
+		Notify(user, subject, body)
+	}
+}
+
+// StartBookingOpenNotifier runs NotifyBookingOpened on a fixed interval, for the lifetime of the process
+func StartBookingOpenNotifier(interval time.Duration) {
+	for {
+		NotifyBookingOpened()
+		time.Sleep(interval)
+	}
+}
+
+// FrontendLink builds a link into the web app for a notification, from FRONTEND_URL
+func FrontendLink(path string, query url.Values) string {
+	base := strings.TrimSuffix(os.Getenv("FRONTEND_URL"), "/")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	if len(query) > 0 {
+		return base + path + "?" + query.Encode()
+	}
+	return base + path
+}
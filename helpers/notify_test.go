@@ -0,0 +1,18 @@
+package helpers
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFrontendLink(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://example.com/")
+	got := FrontendLink("/reset-password", url.Values{"token": {"a b"}})
+	if got != "https://example.com/reset-password?token=a+b" {
+		t.Errorf("unexpected link %q", got)
+	}
+	t.Setenv("FRONTEND_URL", "")
+	if got := FrontendLink("/orders", nil); got != "http://localhost:5173/orders" {
+		t.Errorf("expected the local frontend by default, got %q", got)
+	}
+}
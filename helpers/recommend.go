@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// Weights for each signal when scoring a candidate movie
+const (
+	genreWeight      = 3.0
+	languageWeight   = 2.0
+	castWeight       = 2.0
+	venueWeight      = 1.0
+	coBookingWeight  = 3.0
+	popularityWeight = 0.5
+
+	// A genre or language the user picked in their preferences counts as much as this many bookings
+	statedPreferenceWeight = 2.0
+)
+
+// TasteProfile is how often each attribute shows up in a user's past bookings
+type TasteProfile struct {
+	Genres    map[string]float64
+	Languages map[string]float64
+	Cast      map[string]float64
+	Venues    map[uint]float64
+}
+
+// RecommendationCandidate is a movie that can be recommended, with the signals gathered for it
+type RecommendationCandidate struct {
+	Movie      models.Movie
+	VenueIDs   []uint  // Venues currently playing the movie
+	CoBookings float64 // Users with a shared booking history who booked this movie
+	Popularity float64 // Recent bookings across all users
+}
+
+type Recommendation struct {
+	Movie   models.Movie `json:"movie"`
+	Score   float64      `json:"score"`
+	Reasons []string     `json:"reasons"`
+}
+
+// BuildTasteProfile counts the genres, languages and cast of the watched movies and the venues booked at
+func BuildTasteProfile(watched []models.Movie, venueIDs []uint) TasteProfile {
+	profile := TasteProfile{
+		Genres:    map[string]float64{},
+		Languages: map[string]float64{},
+		Cast:      map[string]float64{},
+		Venues:    map[uint]float64{},
+	}
+	for _, movie := range watched {
+		for _, g := range movie.Genres {
+			profile.Genres[strings.ToLower(g)]++
+		}
+		for _, l := range movie.Languages {
+			profile.Languages[strings.ToLower(l)]++
+		}
+		for _, name := range movie.Cast {
+			profile.Cast[strings.ToLower(name)]++
+		}
+	}
+	for _, id := range venueIDs {
+		profile.Venues[id]++
+	}
+	return profile
+}
+
+// AddPreferences weighs in the languages and genres the user picked, so users without bookings
+// still get recommendations to their taste
+func (p TasteProfile) AddPreferences(prefs models.Preferences) {
+	for _, g := range prefs.Genres {
+		p.Genres[strings.ToLower(g)] += statedPreferenceWeight
+	}
+	for _, l := range prefs.Languages {
+		p.Languages[strings.ToLower(l)] += statedPreferenceWeight
+	}
+}
+
+// IsEmpty is true for users without any booking history or stated preferences
+func (p TasteProfile) IsEmpty() bool {
+	return len(p.Genres) == 0 && len(p.Languages) == 0 && len(p.Cast) == 0 && len(p.Venues) == 0
+}
+
+// affinity is the share of the profile's weight that the values hit, in 0..1
+func affinity(weights map[string]float64, values []string) float64 {
+	var total, hit float64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return 0
+	}
+	seen := map[string]bool{}
+	for _, v := range values {
+		key := strings.ToLower(v)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		hit += weights[key]
+	}
+	return hit / total
+}
+
+// RankMovies scores every candidate against the profile and returns them best first.
+// With an empty profile only co-booking and popularity count, so new users get what is popular.
+func RankMovies(profile TasteProfile, candidates []RecommendationCandidate) []Recommendation {
+	var maxCoBookings, maxPopularity float64
+	for _, c := range candidates {
+		maxCoBookings = max(maxCoBookings, c.CoBookings)
+		maxPopularity = max(maxPopularity, c.Popularity)
+	}
+
+	var venueTotal float64
+	for _, w := range profile.Venues {
+		venueTotal += w
+	}
+
+	recommendations := make([]Recommendation, 0, len(candidates))
+	for _, c := range candidates {
+		var score float64
+		reasons := []string{}
+
+		if a := affinity(profile.Genres, c.Movie.Genres); a > 0 {
+			score += genreWeight * a
+			reasons = append(reasons, "Matches genres you watch")
+		}
+		if a := affinity(profile.Languages, c.Movie.Languages); a > 0 {
+			score += languageWeight * a
+			reasons = append(reasons, "In a language you prefer")
+		}
+		if a := affinity(profile.Cast, c.Movie.Cast); a > 0 {
+			score += castWeight * a
+			reasons = append(reasons, "Features cast you have watched")
+		}
+		if venueTotal > 0 {
+			var hit float64
+			for _, id := range c.VenueIDs {
+				hit += profile.Venues[id]
+			}
+			if hit > 0 {
+				score += venueWeight * min(hit/venueTotal, 1)
+				reasons = append(reasons, "Playing at a venue you visit")
+			}
+		}
+		if maxCoBookings > 0 && c.CoBookings > 0 {
+			score += coBookingWeight * c.CoBookings / maxCoBookings
+			reasons = append(reasons, "Booked by people with similar taste")
+		}
+		if maxPopularity > 0 && c.Popularity > 0 {
+			score += popularityWeight * c.Popularity / maxPopularity
+			if profile.IsEmpty() {
+				reasons = append(reasons, "Popular right now")
+			}
+		}
+
+		recommendations = append(recommendations, Recommendation{Movie: c.Movie, Score: score, Reasons: reasons})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].Movie.Title < recommendations[j].Movie.Title
+	})
+	return recommendations
+}
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+func TestRankMovies_PrefersProfileMatches(t *testing.T) {
+	watched := []models.Movie{
+		{Title: "Inception", Genres: []string{"Sci-Fi", "Thriller"}, Languages: []string{"English"}},
+		{Title: "Interstellar", Genres: []string{"Sci-Fi"}, Languages: []string{"English"}},
+	}
+	profile := BuildTasteProfile(watched, []uint{1})
+
+	candidates := []RecommendationCandidate{
+		{Movie: models.Movie{Title: "Romance Drama", Genres: []string{"Romance"}, Languages: []string{"Hindi"}}, Popularity: 10},
+		{Movie: models.Movie{Title: "Dune", Genres: []string{"sci-fi"}, Languages: []string{"English"}}, VenueIDs: []uint{1}, Popularity: 1},
+	}
+	ranked := RankMovies(profile, candidates)
+
+	if ranked[0].Movie.Title != "Dune" {
+		t.Errorf("expected Dune first, got %s", ranked[0].Movie.Title)
+	}
+	if len(ranked[0].Reasons) == 0 {
+		t.Error("expected reasons for the top recommendation")
+	}
+}
+
+func TestRankMovies_NoHistoryFallsBackToPopularity(t *testing.T) {
+	profile := BuildTasteProfile(nil, nil)
+	if !profile.IsEmpty() {
+		t.Fatal("profile without history should be empty")
+	}
+	candidates := []RecommendationCandidate{
+		{Movie: models.Movie{Title: "Quiet Film"}, Popularity: 2},
+		{Movie: models.Movie{Title: "Blockbuster"}, Popularity: 50},
+	}
+	ranked := RankMovies(profile, candidates)
+	if ranked[0].Movie.Title != "Blockbuster" {
+		t.Errorf("expected Blockbuster first, got %s", ranked[0].Movie.Title)
+	}
+	if len(ranked[0].Reasons) != 1 || ranked[0].Reasons[0] != "Popular right now" {
+		t.Errorf("expected popularity reason, got %v", ranked[0].Reasons)
+	}
+}
+
+func TestRankMovies_UsesStatedPreferences(t *testing.T) {
+	profile := BuildTasteProfile(nil, nil)
+	profile.AddPreferences(models.Preferences{Languages: []string{"Hindi"}, Genres: []string{"Romance"}})
+	if profile.IsEmpty() {
+		t.Fatal("a profile with preferences should not be empty")
+	}
+
+	candidates := []RecommendationCandidate{
+		{Movie: models.Movie{Title: "Blockbuster", Genres: []string{"Action"}, Languages: []string{"English"}}, Popularity: 10},
+		{Movie: models.Movie{Title: "Love Story", Genres: []string{"romance"}, Languages: []string{"hindi"}}, Popularity: 1},
+	}
+	ranked := RankMovies(profile, candidates)
+	if ranked[0].Movie.Title != "Love Story" {
+		t.Errorf("expected the preferred movie first, got %s", ranked[0].Movie.Title)
+	}
+}
@@ -0,0 +1,16 @@
+package helpers
+
+import (
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+// LoadRoles loads the user's role assignments so their permissions can be checked
+func LoadRoles(user *models.User) error {
+	var roles []models.RoleAssignment
+	if err := initializers.Db.Where("user_id = ?", user.ID).Order("id").Find(&roles).Error; err != nil {
+		return err
+	}
+	user.SetRoles(roles)
+	return nil
+}
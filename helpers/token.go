@@ -0,0 +1,205 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ErrLegacyToken is returned for tokens issued before access tokens carried an ID. They can't be revoked,
+// so they are no longer accepted.
+var ErrLegacyToken = errors.New("token has no id")
+
+// AccessClaims are carried by an access token. ID is the token's own id, SessionID the login it belongs to.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid"`
+}
+
+// UserID returns the user the token was issued to
+func (c AccessClaims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id), err
+}
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// AccessTokenTTL is how long an access token is good for, ACCESS_TOKEN_TTL or 15 minutes
+func AccessTokenTTL() time.Duration {
+	return durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL is how long a refresh token is good for, REFRESH_TOKEN_TTL or 30 days
+func RefreshTokenTTL() time.Duration {
+	return durationEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+// NewTokenID returns a random id for an access token or a login
+func NewTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewSecretToken returns a random opaque token, for refresh tokens and reset links, and the hash it is stored under
+func NewSecretToken() (token, hash string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token)
+}
+
+// HashToken is how opaque tokens are stored, so a leaked table can't be replayed
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignAccessToken issues an HS256 access token for the user, signed with SECRET
+func SignAccessToken(userID uint, sessionID, tokenID string, expiresAt time.Time) (string, error) {
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID: sessionID,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("SECRET")))
+}
+
+// ParseAccessToken checks the token's signature and expiry and returns its claims.
+// Expired tokens return an error matching jwt.ErrTokenExpired.
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("SECRET")), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, ErrLegacyToken
+	}
+	return claims, nil
+}
+
+// PruneTokens deletes sessions, refresh tokens, reset links, provider sign-ins and revoked token ids that have expired,
+// since none are accepted anymore
+func PruneTokens() {
+	now := time.Now()
+	initializers.Db.Unscoped().Where("expires_at < ?", now).Delete(&models.PasswordReset{})
+	initializers.Db.Unscoped().Where("expires_at < ?", now).Delete(&models.OIDCLogin{})
+	initializers.Db.Unscoped().Where("expires_at < ?", now).Delete(&models.Session{})
+	initializers.Db.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	initializers.Db.Unscoped().Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+}
+
+// StartTokenPruner runs PruneTokens every interval
+func StartTokenPruner(interval time.Duration) {
+	for {
+		PruneTokens()
+		time.Sleep(interval)
+	}
+}
+
+// Purposes of the short-lived signed tokens that aren't access tokens
+const (
+	emailVerificationPurpose = "verify_email"
+	loginChallengePurpose    = "login_2fa"
+)
+
+// PurposeClaims are carried by a signed token made for one job, such as the link in a verification email.
+// Purpose stops a token made for one job being used for another.
+type PurposeClaims struct {
+	jwt.RegisteredClaims
+	Email   string `json:"email,omitempty"`
+	Purpose string `json:"purpose"`
+}
+
+func signPurposeToken(userID uint, purpose, email string, expiresAt time.Time) (string, error) {
+	claims := PurposeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Email:   email,
+		Purpose: purpose,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("SECRET")))
+}
+
+func parsePurposeToken(tokenString, purpose string) (*PurposeClaims, uint, error) {
+	claims := &PurposeClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("SECRET")), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, 0, err
+	}
+	if claims.Purpose != purpose {
+		return nil, 0, fmt.Errorf("not a %s token", purpose)
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	return claims, uint(userID), err
+}
+
+// SignEmailVerification issues the token for a verification link. The email is included so a link
+// stops working once the address on the account changes.
+func SignEmailVerification(userID uint, email string, expiresAt time.Time) (string, error) {
+	return signPurposeToken(userID, emailVerificationPurpose, email, expiresAt)
+}
+
+// ParseEmailVerification checks a verification token and returns the user and email it was issued for
+func ParseEmailVerification(tokenString string) (uint, string, error) {
+	claims, userID, err := parsePurposeToken(tokenString, emailVerificationPurpose)
+	if err != nil {
+		return 0, "", err
+	}
+	return userID, claims.Email, nil
+}
+
+// SignLoginChallenge issues the token that carries a password login over to its second factor
+func SignLoginChallenge(userID uint, expiresAt time.Time) (string, error) {
+	return signPurposeToken(userID, loginChallengePurpose, "", expiresAt)
+}
+
+// ParseLoginChallenge checks a token from SignLoginChallenge and returns the user it was issued for
+func ParseLoginChallenge(tokenString string) (uint, error) {
+	_, userID, err := parsePurposeToken(tokenString, loginChallengePurpose)
+	return userID, err
+}
+
+// EmailVerificationGrace is how long a new account can book before verifying its email,
+// EMAIL_VERIFICATION_GRACE or none. Handy for development without a mail provider.
+func EmailVerificationGrace() time.Duration {
+	return durationEnv("EMAIL_VERIFICATION_GRACE", 0)
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAccessToken_RoundTrip(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	token, err := SignAccessToken(42, "session-1", "token-1", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims, err := ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id, _ := claims.UserID(); id != 42 || claims.ID != "token-1" || claims.SessionID != "session-1" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	t.Setenv("SECRET", "another-secret")
+	if _, err := ParseAccessToken(token); err == nil {
+		t.Error("expected a token signed with another secret to be rejected")
+	}
+}
+
+func TestAccessToken_RevokedOnNextRequest(t *testing.T) {
+	// Revoking a login denylists the id its access token carries, which is what each request looks up
+	t.Setenv("SECRET", "test-secret")
+	expiresAt := time.Now().Add(AccessTokenTTL())
+	token, _ := SignAccessToken(42, "session-1", "token-1", expiresAt)
+	row := models.RefreshToken{UserID: 42, FamilyID: "session-1", AccessJTI: "token-1", AccessExpiresAt: expiresAt}
+	entry, ok := row.AccessDenial(time.Now())
+	if !ok {
+		t.Fatal("expected the live access token to be denylisted")
+	}
+	claims, err := ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims.ID != entry.JTI || claims.SessionID != row.FamilyID {
+		t.Errorf("the denylist entry %q does not match the token's id %q", entry.JTI, claims.ID)
+	}
+}
+
+func TestAccessToken_Expired(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	token, _ := SignAccessToken(1, "s", "t", time.Now().Add(-time.Minute))
+	if _, err := ParseAccessToken(token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestAccessToken_RejectsLegacyTokens(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": 1,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if _, err := ParseAccessToken(legacy); err == nil {
+		t.Error("expected a token without an id to be rejected")
+	}
+}
+
+func TestNewSecretToken(t *testing.T) {
+	a, hashA := NewSecretToken()
+	b, _ := NewSecretToken()
+	if a == b {
+		t.Error("expected secret tokens to be random")
+	}
+	if hashA != HashToken(a) || hashA == a {
+		t.Error("expected the stored hash to match HashToken and differ from the token")
+	}
+}
+
+func TestTokenTTLs(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_TTL", "")
+	if AccessTokenTTL() != 15*time.Minute {
+		t.Errorf("expected the 15 minute default, got %v", AccessTokenTTL())
+	}
+	t.Setenv("REFRESH_TOKEN_TTL", "72h")
+	if RefreshTokenTTL() != 72*time.Hour {
+		t.Errorf("expected 72h, got %v", RefreshTokenTTL())
+	}
+}
+
+func TestEmailVerification_RoundTrip(t *testing.T) {
+	t.Setenv("SECRET", "test-secret")
+	token, err := SignEmailVerification(7, "a@example.com", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	userID, email, err := ParseEmailVerification(token)
+	if err != nil || userID != 7 || email != "a@example.com" {
+		t.Errorf("unexpected result %d %q %v", userID, email, err)
+	}
+	if _, err := ParseAccessToken(token); err == nil {
+		t.Error("a verification token should not work as an access token")
+	}
+
+	access, _ := SignAccessToken(7, "s", "t", time.Now().Add(time.Hour))
+	if _, _, err := ParseEmailVerification(access); err == nil {
+		t.Error("an access token should not verify an email")
+	}
+	expired, _ := SignEmailVerification(7, "a@example.com", time.Now().Add(-time.Minute))
+	if _, _, err := ParseEmailVerification(expired); err == nil {
+		t.Error("expected an expired link to be rejected")
+	}
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP codes follow RFC 6238 with the defaults every authenticator app understands:
+// SHA-1, 6 digits and a 30 second step
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// Codes from one step either side are accepted to allow for clock drift
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// AdminTwoFactorRequired reports whether admins must turn on two-factor authentication, from ADMIN_2FA_REQUIRED
+func AdminTwoFactorRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("ADMIN_2FA_REQUIRED"))
+	return required
+}
+
+// NewTOTPSecret returns a random 160-bit secret in base32, the form authenticator apps take
+func NewTOTPSecret() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return totpEncoding.EncodeToString(b)
+}
+
+// TOTPStep is the time step t falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode is the code for the secret at a time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// ValidateTOTP checks a code against the steps around now and returns the step it matched.
+// Steps up to lastUsed are refused so a code can't be replayed.
+func ValidateTOTP(secret, code string, now time.Time, lastUsed int64) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsed {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPProvisioningURI is the otpauth:// URI authenticator apps scan from a QR code
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// NewRecoveryCodes returns n one-time codes such as "k3m9-x2pq", and the hashes they are stored under
+func NewRecoveryCodes(n int) (codes, hashes []string) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789" // No 0/o or 1/l/i to misread
+	for i := 0; i < n; i++ {
+		b := make([]byte, 8)
+		for j := range b {
+			k, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			b[j] = alphabet[k.Int64()]
+		}
+		code := string(b[:4]) + "-" + string(b[4:])
+		codes = append(codes, code)
+		hashes = append(hashes, HashToken(code))
+	}
+	return codes, hashes
+}
+
+// NormalizeRecoveryCode lets a recovery code be typed in any case, with or without its dash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) == 8 {
+		return code[:4] + "-" + code[4:]
+	}
+	return code
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA-1 vectors from RFC 6238, truncated to 6 digits
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("TOTPCode at %d = %q, %v; want %q", unix, got, err, want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+	code, _ := TOTPCode(secret, step)
+
+	if got, ok := ValidateTOTP(secret, code, now, 0); !ok || got != step {
+		t.Errorf("expected the current code to match step %d, got %d %v", step, got, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("expected a code from a used step to be refused")
+	}
+	previous, _ := TOTPCode(secret, step-1)
+	if _, ok := ValidateTOTP(secret, previous, now, 0); !ok {
+		t.Error("expected the previous step to be accepted for clock drift")
+	}
+	old, _ := TOTPCode(secret, step-3)
+	if _, ok := ValidateTOTP(secret, old, now, 0); ok {
+		t.Error("expected a code from three steps ago to be refused")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("BookMyShow", "admin@example.com", "JBSWY3DPEHPK3PXP")
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Fatalf("unexpected uri %q", uri)
+	}
+	if !strings.HasPrefix(parsed.Path, "/BookMyShow:admin@example.com") {
+		t.Errorf("unexpected label in %q", uri)
+	}
+	if q := parsed.Query(); q.Get("secret") != "JBSWY3DPEHPK3PXP" || q.Get("issuer") != "BookMyShow" {
+		t.Errorf("unexpected query in %q", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes := NewRecoveryCodes(10)
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("expected 10 codes, got %d", len(codes))
+	}
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != 9 || code[4] != '-' || seen[code] {
+			t.Errorf("unexpected code %q", code)
+		}
+		seen[code] = true
+		if hashes[i] != HashToken(code) {
+			t.Errorf("expected the hash of %q", code)
+		}
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+		if NormalizeRecoveryCode(typed) != code {
+			t.Errorf("expected %q to normalize to %q", typed, code)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// EncodeWebP writes img as a lossless WebP (VP8L) image.
+// The standard library has no WebP encoder, so this is a small one: subtract-green and
+// predictor transforms followed by Huffman coded literals, without LZ77 or a colour cache.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > 1<<14 || height > 1<<14 {
+		return errors.New("webp: image dimensions out of range")
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range argb {
+		p := nrgba.Pix[i*4 : i*4+4]
+		argb[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		if p[3] != 0xff {
+			hasAlpha = true
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.writeBits(0x2f, 8) // VP8L signature
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	// Subtract green transform
+	bw.writeBits(1, 1)
+	bw.writeBits(2, 2)
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		r := ((p>>16)&0xff - green) & 0xff
+		b := (p&0xff - green) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | b
+	}
+
+	// Predictor transform
+	bw.writeBits(1, 1)
+	bw.writeBits(0, 2)
+	bw.writeBits(predictorBits-2, 3)
+	modes := choosePredictors(argb, width, height)
+	writeImageData(bw, modes, false)
+	argb = predictResiduals(argb, width, height, modes)
+
+	bw.writeBits(0, 1) // No more transforms
+	writeImageData(bw, argb, true)
+	bw.flush()
+
+	payload := bw.buf.Bytes()
+	chunkSize := len(payload)
+	padded := chunkSize + chunkSize%2
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+padded))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	if padded != chunkSize {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// Predictor blocks are 1<<predictorBits pixels square
+const predictorBits = 4
+
+// Predictor modes tried per block: left, top and the average of the two
+var predictorModes = []uint32{1, 2, 7}
+
+type bitWriter struct {
+	buf   bytes.Buffer
+	acc   uint64
+	nbits uint
+}
+
+// writeBits writes the low n bits of v, least significant bit first
+func (w *bitWriter) writeBits(v uint32, n uint) {
+	w.acc |= uint64(v&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf.WriteByte(byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// writeCode writes a Huffman code, which goes out most significant bit first
+func (w *bitWriter) writeCode(code uint32, length uint8) {
+	for i := int(length) - 1; i >= 0; i-- {
+		w.writeBits(code>>uint(i)&1, 1)
+	}
+}
+
+func (w *bitWriter) flush() {
+	if w.nbits > 0 {
+		w.buf.WriteByte(byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+}
+
+func subSampleSize(size int) int {
+	return (size + 1<<predictorBits - 1) >> predictorBits
+}
+
+func subPixels(a, b uint32) uint32 {
+	return (0x00ff00ff+(a&0xff00ff00)-(b&0xff00ff00))&0xff00ff00 | (0xff00ff00+(a&0x00ff00ff)-(b&0x00ff00ff))&0x00ff00ff
+}
+
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+func predict(mode uint32, left, top uint32) uint32 {
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	default:
+		return average2(left, top)
+	}
+}
+
+// predictorFor returns the prediction for pixel (x, y) following the VP8L edge rules
+func predictorFor(argb []uint32, width, x, y int, mode uint32) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[x-1]
+	case x == 0:
+		return argb[(y-1)*width]
+	}
+	return predict(mode, argb[y*width+x-1], argb[(y-1)*width+x])
+}
+
+func residualCost(r uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(int8(byte(r >> shift)))
+		if v < 0 {
+			v = -v
+		}
+		cost += v
+	}
+	return cost
+}
+
+// choosePredictors picks the cheapest mode for each block, stored in the green channel as the format expects
+func choosePredictors(argb []uint32, width, height int) []uint32 {
+	tilesX, tilesY := subSampleSize(width), subSampleSize(height)
+	modes := make([]uint32, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			best, bestCost := predictorModes[0], -1
+			for _, mode := range predictorModes {
+				cost := 0
+				for y := ty << predictorBits; y < min((ty+1)<<predictorBits, height); y++ {
+					for x := tx << predictorBits; x < min((tx+1)<<predictorBits, width); x++ {
+						cost += residualCost(subPixels(argb[y*width+x], predictorFor(argb, width, x, y, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[ty*tilesX+tx] = 0xff000000 | best<<8
+		}
+	}
+	return modes
+}
+
+func predictResiduals(argb []uint32, width, height int, modes []uint32) []uint32 {
+	tilesX := subSampleSize(width)
+	residuals := make([]uint32, len(argb))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := (modes[(y>>predictorBits)*tilesX+x>>predictorBits] >> 8) & 0xff
+			residuals[y*width+x] = subPixels(argb[y*width+x], predictorFor(argb, width, x, y, mode))
+		}
+	}
+	return residuals
+}
+
+// writeImageData writes an entropy coded image using literals only
+func writeImageData(bw *bitWriter, argb []uint32, isMain bool) {
+	bw.writeBits(0, 1) // No colour cache
+	if isMain {
+		bw.writeBits(0, 1) // No meta prefix codes
+	}
+	// Green + length prefixes, red, blue, alpha, distance
+	freqs := [5][]int{make([]int, 256+24), make([]int, 256), make([]int, 256), make([]int, 256), make([]int, 40)}
+	for _, p := range argb {
+		freqs[0][(p>>8)&0xff]++
+		freqs[1][(p>>16)&0xff]++
+		freqs[2][p&0xff]++
+		freqs[3][p>>24]++
+	}
+	var lengths [5][]uint8
+	var codes [5][]uint32
+	for i := range freqs {
+		lengths[i] = writePrefixCode(bw, freqs[i])
+		codes[i] = canonicalCodes(lengths[i])
+	}
+	for _, p := range argb {
+		g, r, b, a := (p>>8)&0xff, (p>>16)&0xff, p&0xff, p>>24
+		bw.writeCode(codes[0][g], lengths[0][g])
+		bw.writeCode(codes[1][r], lengths[1][r])
+		bw.writeCode(codes[2][b], lengths[2][b])
+		bw.writeCode(codes[3][a], lengths[3][a])
+	}
+}
+
+// Order in which code length code lengths are stored
+var codeLengthOrder = []int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// writePrefixCode writes the Huffman code for one alphabet and returns its code lengths
+func writePrefixCode(bw *bitWriter, freqs []int) []uint8 {
+	var used []int
+	for symbol, f := range freqs {
+		if f > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) <= 1 {
+		// Simple code with a single symbol, which then takes no bits per pixel
+		symbol := 0
+		if len(used) == 1 {
+			symbol = used[0]
+		}
+		lengths := make([]uint8, len(freqs))
+		bw.writeBits(1, 1) // simple
+		bw.writeBits(0, 1) // one symbol
+		if symbol < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(symbol), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(symbol), 8)
+		}
+		return lengths
+	}
+
+	lengths := huffmanLengths(freqs, 15)
+
+	// The code lengths are themselves Huffman coded, one literal length per symbol
+	lengthFreqs := make([]int, 19)
+	for _, l := range lengths {
+		lengthFreqs[l]++
+	}
+	ensureTwoSymbols(lengthFreqs)
+	lengthLengths := huffmanLengths(lengthFreqs, 7)
+	lengthCodes := canonicalCodes(lengthLengths)
+
+	count := len(codeLengthOrder)
+	for count > 4 && lengthLengths[codeLengthOrder[count-1]] == 0 {
+		count--
+	}
+	bw.writeBits(0, 1) // normal
+	bw.writeBits(uint32(count-4), 4)
+	for _, symbol := range codeLengthOrder[:count] {
+		bw.writeBits(uint32(lengthLengths[symbol]), 3)
+	}
+	bw.writeBits(0, 1) // Every symbol's length follows
+	for _, l := range lengths {
+		bw.writeCode(lengthCodes[l], lengthLengths[l])
+	}
+	return lengths
+}
+
+// ensureTwoSymbols gives a code at least two symbols, since a lone normal-coded symbol is invalid
+func ensureTwoSymbols(freqs []int) {
+	used := 0
+	for _, f := range freqs {
+		if f > 0 {
+			used++
+		}
+	}
+	for i := 0; used < 2 && i < len(freqs); i++ {
+		if freqs[i] == 0 {
+			freqs[i] = 1
+			used++
+		}
+	}
+}
+
+// huffmanLengths builds Huffman code lengths no longer than maxLength.
+// When the tree is too deep the counts are halved and it is rebuilt.
+func huffmanLengths(freqs []int, maxLength int) []uint8 {
+	counts := append([]int(nil), freqs...)
+	for {
+		lengths := buildHuffman(counts)
+		longest := 0
+		for _, l := range lengths {
+			longest = max(longest, int(l))
+		}
+		if longest <= maxLength {
+			return lengths
+		}
+		for i, c := range counts {
+			if c > 0 {
+				counts[i] = (c + 1) / 2
+			}
+		}
+	}
+}
+
+func buildHuffman(freqs []int) []uint8 {
+	type node struct {
+		weight      int
+		symbol      int
+		left, right int
+	}
+	var nodes []node
+	var queue []int
+	for symbol, f := range freqs {
+		if f > 0 {
+			nodes = append(nodes, node{weight: f, symbol: symbol, left: -1, right: -1})
+			queue = append(queue, len(nodes)-1)
+		}
+	}
+	for len(queue) > 1 {
+		sort.SliceStable(queue, func(i, j int) bool { return nodes[queue[i]].weight < nodes[queue[j]].weight })
+		a, b := queue[0], queue[1]
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		queue = append(queue[2:], len(nodes)-1)
+	}
+	lengths := make([]uint8, len(freqs))
+	var walk func(n int, depth uint8)
+	walk = func(n int, depth uint8) {
+		if nodes[n].symbol >= 0 {
+			lengths[nodes[n].symbol] = depth
+			return
+		}
+		walk(nodes[n].left, depth+1)
+		walk(nodes[n].right, depth+1)
+	}
+	if len(queue) == 1 {
+		walk(queue[0], 0)
+	}
+	return lengths
+}
+
+// canonicalCodes assigns codes to lengths the same way DEFLATE does
+func canonicalCodes(lengths []uint8) []uint32 {
+	var blCount [16]uint32
+	for _, l := range lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+	var nextCode [16]uint32
+	code := uint32(0)
+	for bits := 1; bits < 16; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		nextCode[bits] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for symbol, l := range lengths {
+		if l > 0 {
+			codes[symbol] = nextCode[l]
+			nextCode[l]++
+		}
+	}
+	return codes
+}
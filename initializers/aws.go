@@ -1,37 +0,0 @@
-package initializers
-
-import (
-	"log"
-	"os"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-)
-
-var Uploader *s3manager.Uploader
-
-func CreateAWSUploader() {
-
-	region := os.Getenv("AWS_REGION")
-	accessKey := os.Getenv("AWS_ACCESS_KEY")
-	secretKey := os.Getenv("AWS_SECRET_KEY")
-
-	awsSession, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region: aws.String(region),
-			Credentials: credentials.NewStaticCredentials(
-				accessKey,
-				secretKey,
-				"",
-			),
-		},
-	})
-
-	if err != nil {
-		log.Fatal("Error creating an aws session")
-	}
-
-	Uploader = s3manager.NewUploader(awsSession)
-}
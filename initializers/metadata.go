@@ -0,0 +1,25 @@
+package initializers
+
+import (
+	"log"
+	"os"
+
+	"github.com/Snehil208001/BookMyShowApp/metadata"
+)
+
+var Metadata metadata.MetadataProvider
+
+// CreateMetadataProvider picks the movie metadata source from METADATA_PROVIDER.
+// Only the file provider ships today; it reads JSON files from METADATA_DIR.
+func CreateMetadataProvider() {
+	switch provider := os.Getenv("METADATA_PROVIDER"); provider {
+	case "", "file":
+		dir := os.Getenv("METADATA_DIR")
+		if dir == "" {
+			dir = "testdata/metadata"
+		}
+		Metadata = metadata.NewFileProvider(dir)
+	default:
+		log.Fatalf("Unknown METADATA_PROVIDER %q", provider)
+	}
+}
@@ -1,14 +1,57 @@
 package initializers
 
-import "github.com/Snehil208001/BookMyShowApp/models"
+import (
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+)
 
 func SyncDB() {
+	// Accounts made before email verification existed are treated as verified
+	backfillVerified := !Db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	Db.AutoMigrate(
 		&models.Movie{},
 		&models.User{},
+		&models.Operator{},
+		&models.Region{},
+		&models.City{},
 		&models.Venue{},
+		&models.Screen{},
 		&models.ShowTime{},
 		&models.Seat{},
 		&models.Order{},
+		&models.MovieInterest{},
+		&models.Notification{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.Session{},
+		&models.PasswordReset{},
+		&models.OTPChallenge{},
+		&models.Identity{},
+		&models.OIDCLogin{},
+		&models.RoleAssignment{},
+		&models.AuditLog{},
+		&models.Review{},
+		&models.ReviewVote{},
+		&models.MovieCredit{},
+		&models.MovieMedia{},
 	)
+
+	// Codes used to be kept on the user, they now stay with the OTP provider
+	if Db.Migrator().HasColumn(&models.User{}, "otp") {
+		Db.Migrator().DropColumn(&models.User{}, "otp")
+	}
+	// Admins used to be flagged on the user, with operator_id set for operator admins. They are role assignments now.
+	if Db.Migrator().HasColumn(&models.User{}, "is_admin") {
+		err := Db.Exec(`INSERT INTO role_assignments (created_at, updated_at, user_id, role, operator_id)
+			SELECT NOW(), NOW(), id, CASE WHEN operator_id IS NULL THEN ? ELSE ? END, operator_id
+			FROM users WHERE is_admin AND deleted_at IS NULL`, models.RoleSuperAdmin, models.RoleOperatorAdmin).Error
+		if err == nil {
+			Db.Migrator().DropColumn(&models.User{}, "is_admin")
+			Db.Migrator().DropColumn(&models.User{}, "operator_id")
+		}
+	}
+	if backfillVerified {
+		Db.Model(&models.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+	}
 }
@@ -0,0 +1,34 @@
+package initializers
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Snehil208001/BookMyShowApp/oidc"
+)
+
+// OIDC holds the providers users can sign in with, by name
+var OIDC = map[string]*oidc.Provider{}
+
+// CreateOIDCProviders sets up the providers listed in OIDC_PROVIDERS, e.g. "google,microsoft". Each is
+// configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET.
+func CreateOIDCProviders() {
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := oidc.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			log.Fatalf("OIDC provider %q needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		OIDC[name] = oidc.NewProvider(cfg)
+	}
+}
@@ -0,0 +1,23 @@
+package initializers
+
+import (
+	"log"
+	"os"
+
+	"github.com/Snehil208001/BookMyShowApp/otp"
+)
+
+var OTP otp.OTPProvider
+
+// CreateOTPProvider picks how login and verification codes are sent from OTP_PROVIDER:
+// local (default) logs codes instead of texting them, twilio uses a Twilio Verify service.
+func CreateOTPProvider() {
+	switch provider := os.Getenv("OTP_PROVIDER"); provider {
+	case "", otp.ProviderLocal:
+		OTP = otp.NewLocalProvider(otp.DefaultPolicy.TTL)
+	case otp.ProviderTwilio:
+		OTP = otp.NewTwilioProvider(os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN"), os.Getenv("TWILIO_SERVICE_SID"))
+	default:
+		log.Fatalf("Unknown OTP_PROVIDER %q", provider)
+	}
+}
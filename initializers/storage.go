@@ -0,0 +1,66 @@
+package initializers
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/Snehil208001/BookMyShowApp/storage"
+)
+
+var Blobs storage.BlobStore
+
+// CreateBlobStore picks where uploads are kept from STORAGE_BACKEND: local, s3 or s3-compatible. Unset, it is
+// s3 when AWS_BUCKET_NAME is set, as uploads always went to S3 before there was a choice, and local otherwise.
+// Local storage needs no credentials, so the server starts without any cloud account.
+func CreateBlobStore() {
+	var err error
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = storage.BackendLocal
+		if os.Getenv("AWS_BUCKET_NAME") != "" {
+			backend = storage.BackendS3
+		}
+	}
+	switch backend {
+	case storage.BackendLocal:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		publicURL := os.Getenv("STORAGE_PUBLIC_URL")
+		if publicURL == "" {
+			port := os.Getenv("PORT")
+			if port == "" {
+				port = "8080"
+			}
+			publicURL = "http://localhost:" + port + "/uploads"
+		}
+		Blobs, err = storage.NewLocalStore(dir, publicURL)
+	case storage.BackendS3, storage.BackendS3Compatible:
+		config := storage.S3Config{
+			Bucket:    os.Getenv("AWS_BUCKET_NAME"),
+			Region:    os.Getenv("AWS_REGION"),
+			AccessKey: os.Getenv("AWS_ACCESS_KEY"),
+			SecretKey: os.Getenv("AWS_SECRET_KEY"),
+			PublicURL: os.Getenv("STORAGE_PUBLIC_URL"),
+		}
+		if backend == storage.BackendS3Compatible {
+			config.Endpoint = os.Getenv("S3_ENDPOINT")
+			if config.Endpoint == "" {
+				log.Fatal("S3_ENDPOINT is required for the s3-compatible storage backend")
+			}
+			// Most self-hosted services only understand path-style addressing
+			config.PathStyle = true
+			if v := os.Getenv("S3_FORCE_PATH_STYLE"); v != "" {
+				config.PathStyle, _ = strconv.ParseBool(v)
+			}
+		}
+		Blobs, err = storage.NewS3Store(config)
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q", backend)
+	}
+	if err != nil {
+		log.Fatalf("Error creating %s blob store: %v", backend, err)
+	}
+}
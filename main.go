@@ -1,16 +1,25 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
+	"github.com/Snehil208001/BookMyShowApp/metadata"
 	"github.com/Snehil208001/BookMyShowApp/routes"
+	"github.com/Snehil208001/BookMyShowApp/storage"
 )
 
 func init() {
 	initializers.LoadEnv()
 	initializers.ConnectToDB()
 	initializers.SyncDB()
-	initializers.CreateAWSUploader()
+	initializers.CreateBlobStore()
+	initializers.CreateMetadataProvider()
+	initializers.CreateOTPProvider()
+	initializers.CreateOIDCProviders()
 }
 
 var R = gin.Default()
@@ -38,10 +47,33 @@ func main() {
 		}
 		c.Next()
 	})
+	// Uploads kept on local disk are served by the API itself
+	if local, ok := initializers.Blobs.(*storage.LocalStore); ok {
+		R.Static(local.ServePath(), local.Dir)
+	}
 	routes.MovieRoutes(R)
 	routes.UserRoutes(R)
 	routes.VenueRoutes(R)
+	routes.CityRoutes(R)
+	routes.OperatorRoutes(R)
 	routes.SeatRoutes(R)
 	routes.OrderRoutes(R)
+	routes.ReviewRoutes(R)
+	routes.AdminRoutes(R)
+
+	// Let users who registered interest know once booking opens
+	go helpers.StartBookingOpenNotifier(5 * time.Minute)
+
+	// Drop refresh tokens and revoked token ids once they have expired
+	go helpers.StartTokenPruner(time.Hour)
+
+	// Anonymize accounts whose deletion cooling-off period is over
+	go helpers.StartAccountDeleter(time.Hour)
+
+	// Keep imported movies in step with the metadata provider
+	if interval, err := time.ParseDuration(os.Getenv("METADATA_SYNC_INTERVAL")); err == nil && interval > 0 {
+		go metadata.NewImporter(initializers.Metadata, initializers.Db).RunResync(interval)
+	}
+
 	R.Run()
 }
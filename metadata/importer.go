@@ -0,0 +1,240 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"gorm.io/gorm"
+)
+
+// SyncResult says what a refresh did to one movie
+type SyncResult struct {
+	MovieID    uint     `json:"movie_id"`
+	ExternalID string   `json:"external_id"`
+	Created    bool     `json:"created"`
+	Updated    []string `json:"updated"` // Fields taken from the provider
+	Kept       []string `json:"kept"`    // Fields left alone because an admin edited them
+	Error      string   `json:"error,omitempty"`
+}
+
+// Importer creates and refreshes movies from a provider
+type Importer struct {
+	Provider MetadataProvider
+	DB       *gorm.DB
+}
+
+func NewImporter(provider MetadataProvider, db *gorm.DB) *Importer {
+	return &Importer{Provider: provider, DB: db}
+}
+
+// syncedField is a movie field the provider can fill, read and written as a string
+type syncedField struct {
+	name     string
+	get      func(m *models.Movie) string
+	set      func(m *models.Movie, value string)
+	incoming func(md *MovieMetadata) string
+}
+
+// Lists are compared as one string
+const listJoin = "|"
+
+var syncedFields = []syncedField{
+	{"title",
+		func(m *models.Movie) string { return m.Title },
+		func(m *models.Movie, v string) { m.Title = v },
+		func(md *MovieMetadata) string { return md.Title }},
+	{"desc",
+		func(m *models.Movie) string { return m.Description },
+		func(m *models.Movie, v string) { m.Description = v },
+		func(md *MovieMetadata) string { return md.Overview }},
+	{"duration",
+		func(m *models.Movie) string { return m.Duration },
+		func(m *models.Movie, v string) { m.Duration = v },
+		func(md *MovieMetadata) string { return md.Duration() }},
+	{"poster",
+		func(m *models.Movie) string { return m.Poster },
+		func(m *models.Movie, v string) { m.Poster = v },
+		func(md *MovieMetadata) string { return md.PosterURL }},
+	{"trailer_url",
+		func(m *models.Movie) string { return m.TrailerURL },
+		func(m *models.Movie, v string) { m.TrailerURL = v },
+		func(md *MovieMetadata) string { return md.TrailerURL }},
+	{"genres",
+		func(m *models.Movie) string { return strings.Join(m.Genres, listJoin) },
+		func(m *models.Movie, v string) { m.Genres = strings.Split(v, listJoin) },
+		func(md *MovieMetadata) string { return strings.Join(md.Genres, listJoin) }},
+	{"languages",
+		func(m *models.Movie) string { return strings.Join(m.Languages, listJoin) },
+		func(m *models.Movie, v string) { m.Languages = strings.Split(v, listJoin) },
+		func(md *MovieMetadata) string { return strings.Join(md.Languages, listJoin) }},
+	{"cast",
+		func(m *models.Movie) string { return strings.Join(m.Cast, listJoin) },
+		func(m *models.Movie, v string) { m.Cast = strings.Split(v, listJoin) },
+		func(md *MovieMetadata) string { return strings.Join(md.CastNames(), listJoin) }},
+	{"release_date",
+		func(m *models.Movie) string {
+			if m.ReleaseDate == nil {
+				return ""
+			}
+			return m.ReleaseDate.Format("2006-01-02")
+		},
+		func(m *models.Movie, v string) {
+			if t, err := time.Parse("2006-01-02", v); err == nil {
+				m.ReleaseDate = &t
+			}
+		},
+		func(md *MovieMetadata) string {
+			if md.ReleaseDate == nil {
+				return ""
+			}
+			return md.ReleaseDate.Format("2006-01-02")
+		}},
+}
+
+// mergeField decides whether a field takes the provider's value. A field is only
+// overwritten while it is empty or still holds what the provider sent last time.
+func mergeField(snapshot map[string]string, name, current, incoming string) (value string, changed, kept bool) {
+	if incoming == "" || current == incoming {
+		return current, false, false
+	}
+	last, synced := snapshot[name]
+	if current == "" || (synced && current == last) {
+		return incoming, true, false
+	}
+	return current, false, true
+}
+
+// apply merges provider metadata into the movie and returns what changed
+func apply(movie *models.Movie, md *MovieMetadata, source string) (updated, kept []string) {
+	if movie.MetadataSnapshot == nil {
+		movie.MetadataSnapshot = map[string]string{}
+	}
+	updated, kept = []string{}, []string{}
+	for _, f := range syncedFields {
+		incoming := f.incoming(md)
+		value, changed, wasKept := mergeField(movie.MetadataSnapshot, f.name, f.get(movie), incoming)
+		if changed {
+			f.set(movie, value)
+			updated = append(updated, f.name)
+		}
+		if wasKept {
+			kept = append(kept, f.name)
+		}
+		if incoming != "" {
+			movie.MetadataSnapshot[f.name] = incoming
+		}
+	}
+	now := time.Now()
+	movie.ExternalID = md.ExternalID
+	movie.MetadataSource = source
+	movie.MetadataSyncedAt = &now
+	return updated, kept
+}
+
+// Import creates the movie for an external ID, or refreshes it if it was imported before
+func (i *Importer) Import(ctx context.Context, externalID string) (*models.Movie, *SyncResult, error) {
+	var movie models.Movie
+	err := i.DB.Where("external_id = ?", externalID).First(&movie).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if movie.ID == 0 {
+		movie.ExternalID = externalID
+	}
+	result, err := i.sync(ctx, &movie)
+	return &movie, result, err
+}
+
+// Link attaches an existing movie to an external ID and fills in what it is missing
+func (i *Importer) Link(ctx context.Context, movie *models.Movie, externalID string) (*SyncResult, error) {
+	if movie.ExternalID != externalID {
+		// Values synced from another title say nothing about this one
+		movie.MetadataSnapshot = nil
+	}
+	movie.ExternalID = externalID
+	return i.sync(ctx, movie)
+}
+
+// Refresh pulls the latest metadata for a movie that already has an external ID
+func (i *Importer) Refresh(ctx context.Context, movie *models.Movie) (*SyncResult, error) {
+	return i.sync(ctx, movie)
+}
+
+func (i *Importer) sync(ctx context.Context, movie *models.Movie) (*SyncResult, error) {
+	md, err := i.Provider.FetchMovie(ctx, movie.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	md.ExternalID = movie.ExternalID
+	created := movie.ID == 0
+	updated, kept := apply(movie, md, i.Provider.Name())
+
+	err = i.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(movie).Error; err != nil {
+			return err
+		}
+		// Credits only ever come from the provider, so replace them wholesale
+		if err := tx.Unscoped().Where("movie_id = ?", movie.ID).Delete(&models.MovieCredit{}).Error; err != nil {
+			return err
+		}
+		if len(md.Credits) == 0 {
+			return nil
+		}
+		credits := make([]models.MovieCredit, len(md.Credits))
+		for n, credit := range md.Credits {
+			credits[n] = models.MovieCredit{
+				MovieID:   movie.ID,
+				Name:      credit.Name,
+				Role:      credit.Role,
+				Character: credit.Character,
+				SortOrder: credit.Order,
+			}
+		}
+		return tx.Create(&credits).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SyncResult{
+		MovieID:    movie.ID,
+		ExternalID: movie.ExternalID,
+		Created:    created,
+		Updated:    updated,
+		Kept:       kept,
+	}, nil
+}
+
+// ResyncAll refreshes every movie linked to an external ID, carrying on past failures
+func (i *Importer) ResyncAll(ctx context.Context) []SyncResult {
+	var movies []models.Movie
+	i.DB.Where("external_id <> ''").Find(&movies)
+	results := make([]SyncResult, 0, len(movies))
+	for n := range movies {
+		result, err := i.Refresh(ctx, &movies[n])
+		if err != nil {
+			results = append(results, SyncResult{MovieID: movies[n].ID, ExternalID: movies[n].ExternalID, Error: err.Error()})
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results
+}
+
+// RunResync re-syncs all linked movies on a fixed interval, for the lifetime of the process
+func (i *Importer) RunResync(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		results := i.ResyncAll(context.Background())
+		var failed int
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		log.Printf("[metadata] re-synced %d movies from %s, %d failed\n", len(results), i.Provider.Name(), failed)
+	}
+}
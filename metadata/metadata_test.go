@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+func TestFileProvider_FetchMovie(t *testing.T) {
+	provider := NewFileProvider("../testdata/metadata")
+	md, err := provider.FetchMovie(context.Background(), "tt1375666")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.Title != "Inception" {
+		t.Errorf("expected Inception, got %s", md.Title)
+	}
+	if md.Duration() != "2h 28m" {
+		t.Errorf("expected 2h 28m, got %s", md.Duration())
+	}
+	if cast := md.CastNames(); len(cast) == 0 || cast[0] != "Leonardo DiCaprio" {
+		t.Errorf("unexpected cast: %v", cast)
+	}
+}
+
+func TestFileProvider_NotFound(t *testing.T) {
+	provider := NewFileProvider("../testdata/metadata")
+	for _, id := range []string{"tt0000000", "../go.mod", ""} {
+		if _, err := provider.FetchMovie(context.Background(), id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%q: expected ErrNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestApply_KeepsAdminEdits(t *testing.T) {
+	md := &MovieMetadata{ExternalID: "tt1", Title: "Original Title", Overview: "Provider plot", RuntimeMinutes: 90}
+	movie := models.Movie{}
+
+	// First sync fills everything in
+	updated, kept := apply(&movie, md, "file")
+	if movie.Title != "Original Title" || movie.Duration != "1h 30m" || len(kept) != 0 {
+		t.Fatalf("first sync should fill the movie, got %+v (updated %v, kept %v)", movie, updated, kept)
+	}
+
+	// An admin rewrites the description, then the provider changes both fields
+	movie.Description = "Admin plot"
+	md.Title = "Renamed Title"
+	md.Overview = "New provider plot"
+	updated, kept = apply(&movie, md, "file")
+
+	if movie.Title != "Renamed Title" {
+		t.Errorf("untouched field should follow the provider, got %q", movie.Title)
+	}
+	if movie.Description != "Admin plot" {
+		t.Errorf("admin edit should be kept, got %q", movie.Description)
+	}
+	if len(kept) != 1 || kept[0] != "desc" {
+		t.Errorf("expected desc to be reported as kept, got %v", kept)
+	}
+	if len(updated) != 1 || updated[0] != "title" {
+		t.Errorf("expected only title to be updated, got %v", updated)
+	}
+}
+
+func TestApply_FillsEmptyFieldsOnLink(t *testing.T) {
+	// A hand-made movie linked for the first time keeps what the admin typed and gains the rest
+	movie := models.Movie{Title: "Hand Typed", Duration: "2h"}
+	md := &MovieMetadata{ExternalID: "tt2", Title: "Provider Title", Overview: "Plot", RuntimeMinutes: 125, Genres: []string{"Drama"}}
+	apply(&movie, md, "file")
+	if movie.Title != "Hand Typed" || movie.Duration != "2h" {
+		t.Errorf("existing values should be kept, got %q / %q", movie.Title, movie.Duration)
+	}
+	if movie.Description != "Plot" || len(movie.Genres) != 1 {
+		t.Errorf("empty fields should be filled, got %q / %v", movie.Description, movie.Genres)
+	}
+}
@@ -0,0 +1,100 @@
+// Package metadata fills in movie details from an external catalog such as TMDB or IMDb.
+// Sources plug in through MetadataProvider; FileProvider reads JSON files for offline work and tests.
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var ErrNotFound = errors.New("movie not found in metadata provider")
+
+// Credit is one person on a movie
+type Credit struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`      // cast, director, writer, producer, music
+	Character string `json:"character"` // For cast only
+	Order     int    `json:"order"`
+}
+
+// MovieMetadata is what a provider knows about a movie
+type MovieMetadata struct {
+	ExternalID     string     `json:"external_id"`
+	Title          string     `json:"title"`
+	Overview       string     `json:"overview"`
+	RuntimeMinutes int        `json:"runtime_minutes"`
+	ReleaseDate    *time.Time `json:"release_date"`
+	Genres         []string   `json:"genres"`
+	Languages      []string   `json:"languages"`
+	PosterURL      string     `json:"poster_url"`
+	TrailerURL     string     `json:"trailer_url"`
+	Credits        []Credit   `json:"credits"`
+}
+
+// Duration formats the runtime the way movies store it, e.g. "2h 28m"
+func (m *MovieMetadata) Duration() string {
+	if m.RuntimeMinutes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dh %dm", m.RuntimeMinutes/60, m.RuntimeMinutes%60)
+}
+
+// CastNames lists the cast in billing order
+func (m *MovieMetadata) CastNames() []string {
+	var names []string
+	for _, credit := range m.Credits {
+		if credit.Role == "cast" {
+			names = append(names, credit.Name)
+		}
+	}
+	return names
+}
+
+// MetadataProvider looks up movies by the provider's own ID
+type MetadataProvider interface {
+	Name() string
+	FetchMovie(ctx context.Context, externalID string) (*MovieMetadata, error)
+}
+
+// External IDs become file names, so keep them to a safe character set
+var externalIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// FileProvider serves metadata from <Dir>/<external id>.json
+type FileProvider struct {
+	Dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{Dir: dir}
+}
+
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+func (p *FileProvider) FetchMovie(ctx context.Context, externalID string) (*MovieMetadata, error) {
+	if !externalIDPattern.MatchString(externalID) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, externalID+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var movie MovieMetadata
+	if err := json.Unmarshal(data, &movie); err != nil {
+		return nil, fmt.Errorf("invalid metadata file for %s: %w", externalID, err)
+	}
+	if movie.ExternalID == "" {
+		movie.ExternalID = externalID
+	}
+	return &movie, nil
+}
@@ -1,18 +1,19 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/Snehil208001/BookMyShowApp/helpers"
 	"github.com/Snehil208001/BookMyShowApp/initializers"
 	"github.com/Snehil208001/BookMyShowApp/models"
 )
 
-func RequireAuth(c *gin.Context) {
+// authenticate finds the user behind the request's access token. On failure it returns the reason for the 401.
+func authenticate(c *gin.Context) (models.User, *helpers.AccessClaims, string) {
 	tokenString, _ := c.Cookie("Authorization")
 
 	// Fallback: check Authorization header (Bearer token) for mobile apps
@@ -24,45 +25,95 @@ func RequireAuth(c *gin.Context) {
 	}
 
 	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
-		c.Abort()
-		return
+		return models.User{}, nil, "Authorization required"
+	}
+
+	claims, err := helpers.ParseAccessToken(tokenString)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return models.User{}, nil, "Token expired"
+	}
+	if err != nil {
+		return models.User{}, nil, "Invalid token"
+	}
+
+	// Logging out or revoking sessions denylists the token until it expires
+	var revoked int64
+	initializers.Db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&revoked)
+	if revoked > 0 {
+		return models.User{}, nil, "Token revoked"
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	// Revoking a session cuts off every token it issued. Its last seen time is kept to within a minute.
+	var session models.Session
+	initializers.Db.Where("family_id = ?", claims.SessionID).Limit(1).Find(&session)
+	if session.ID != 0 {
+		if session.IsRevoked() {
+			return models.User{}, nil, "Session revoked"
+		}
+		if time.Since(session.LastSeenAt) > time.Minute {
+			initializers.Db.Model(&session).UpdateColumn("last_seen_at", time.Now())
 		}
+	}
 
-		return []byte(os.Getenv("SECRET")), nil
-	})
+	userID, err := claims.UserID()
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return models.User{}, nil, "Invalid token claims"
+	}
+	var user models.User
+	initializers.Db.First(&user, userID)
+
+	if user.ID == 0 {
+		return models.User{}, nil, "User not found"
+	}
+	if user.IsDisabled() {
+		return models.User{}, nil, "Account disabled"
+	}
+	if err := helpers.LoadRoles(&user); err != nil {
+		return models.User{}, nil, "Failed to load roles"
+	}
+
+	// Under ADMIN_2FA_REQUIRED staff keep only customer access until they turn on two-factor authentication
+	if user.NeedsTwoFactorEnrolment(helpers.AdminTwoFactorRequired()) {
+		user.SetRoles(nil)
+		c.Set("two_factor_enrolment_required", true)
+	}
+	return user, claims, ""
+}
+
+func RequireAuth(c *gin.Context) {
+	user, claims, reason := authenticate(c)
+	if reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": reason})
 		c.Abort()
 		return
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-			c.Abort()
-			return
-		}
+	c.Set("user", user)
+	c.Set("token", *claims)
 
-		var user models.User
-		initializers.Db.First(&user, claims["sub"])
+	c.Next()
+}
 
-		if user.ID == 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+// RequirePermission lets the request through when the user, set by RequireAuth, has the permission through any
+// of their roles. Handlers for venue or operator resources still check the role covers the one asked for.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, _ := c.Get("user")
+		if userDetails, ok := user.(models.User); !ok || !userDetails.Can(perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do this"})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
+// OptionalAuth sets the user when the request carries a valid token and lets guests through otherwise.
+// Public endpoints use it to personalise, e.g. scoping listings to the user's preferred city.
+func OptionalAuth(c *gin.Context) {
+	if user, claims, reason := authenticate(c); reason == "" {
 		c.Set("user", user)
-
-		c.Next()
-	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-		c.Abort()
+		c.Set("token", *claims)
 	}
+	c.Next()
 }
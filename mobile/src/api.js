@@ -15,6 +15,27 @@ api.interceptors.request.use(async (config) => {
   return config
 })
 
+// Access tokens are short-lived: on a 401, swap the stored refresh token for new tokens once and retry
+api.interceptors.response.use(undefined, async (error) => {
+  const config = error.config
+  if (error.response?.status !== 401 || !config || config._retried || config.url.startsWith('/user/token') || config.url === '/user/login') {
+    return Promise.reject(error)
+  }
+  config._retried = true
+  const refreshToken = await AsyncStorage.getItem('refresh_token')
+  if (!refreshToken) {
+    return Promise.reject(error)
+  }
+  try {
+    const { data } = await api.post('/user/token/refresh', { refresh_token: refreshToken })
+    await AsyncStorage.multiSet([['token', data.token], ['refresh_token', data.refresh_token]])
+  } catch (e) {
+    await AsyncStorage.multiRemove(['token', 'refresh_token'])
+    return Promise.reject(error)
+  }
+  return api(config)
+})
+
 // Movies
 export const getMovies = (params) => api.get('/movies/', { params })
 export const getMovie = (id) => api.get(`/movies/${id}`)
@@ -10,7 +10,7 @@ export default function HeaderRight({ navigation }) {
     try {
       await logout()
     } catch (e) {}
-    await AsyncStorage.removeItem('token')
+    await AsyncStorage.multiRemove(['token', 'refresh_token'])
     setUser(null)
     navigation.navigate('Home')
   }
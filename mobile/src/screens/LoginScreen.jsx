@@ -26,7 +26,7 @@ export default function LoginScreen({ navigation, route }) {
     try {
       const { data } = await login({ email, password })
       if (data.token) {
-        await AsyncStorage.setItem('token', data.token)
+        await AsyncStorage.multiSet([['token', data.token], ['refresh_token', data.refresh_token]])
       }
       setUser(data.user)
       const from = route.params?.from
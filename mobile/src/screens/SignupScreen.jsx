@@ -28,7 +28,7 @@ export default function SignupScreen({ navigation }) {
       await signup({ name, email, password })
       const { data } = await login({ email, password })
       if (data.token) {
-        await AsyncStorage.setItem('token', data.token)
+        await AsyncStorage.multiSet([['token', data.token], ['refresh_token', data.refresh_token]])
       }
       setUser(data.user)
       navigation.replace('Home')
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Actions recorded in the audit log
+const (
+	AuditUserDisabled       = "user.disabled"
+	AuditUserEnabled        = "user.enabled"
+	AuditPasswordResetForce = "user.password_reset_forced"
+	AuditSessionsRevoked    = "user.sessions_revoked"
+	AuditRoleGranted        = "role.granted"
+	AuditRoleRevoked        = "role.revoked"
+	AuditUserAnonymized     = "user.anonymized" // The user's deletion went through after the cooling-off period
+)
+
+// AuditLog records an action taken on a user's account by staff, or by the system with no actor.
+// Entries are never changed or deleted.
+type AuditLog struct {
+	ID           uint                   `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time              `json:"created_at" gorm:"index"`
+	ActorID      *uint                  `json:"actor_id" gorm:"index"` // Nil for command line tools and background jobs
+	Action       string                 `json:"action" gorm:"not null;index"`
+	TargetUserID uint                   `json:"target_user_id" gorm:"not null;index"`
+	Details      map[string]interface{} `json:"details,omitempty" gorm:"serializer:json"`
+	IP           string                 `json:"ip,omitempty"`
+}
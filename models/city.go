@@ -0,0 +1,73 @@
+package models
+
+import "gorm.io/gorm"
+
+// TaxProfile is the tax charged on tickets
+type TaxProfile struct {
+	Name      string  `json:"name"` // e.g. "GST"
+	Rate      float64 `json:"rate"` // Percent
+	Inclusive bool    `json:"inclusive"`
+}
+
+// Region groups cities that share a timezone, currency and tax profile, e.g. a country or state
+type Region struct {
+	gorm.Model
+	Name       string      `json:"name" gorm:"not null"`
+	Code       string      `json:"code" gorm:"not null;uniqueIndex"`
+	Timezone   string      `json:"timezone" gorm:"not null"` // IANA name, e.g. Asia/Kolkata
+	Currency   string      `json:"currency" gorm:"not null"` // ISO 4217, e.g. INR
+	TaxProfile *TaxProfile `json:"tax_profile" gorm:"serializer:json"`
+	Enabled    bool        `json:"enabled" gorm:"default:true"`
+}
+
+// City groups venues. Its coordinates are the city centre, used when a venue has none of its own.
+// Timezone, currency and tax profile fall back to the region's when empty.
+type City struct {
+	gorm.Model
+	Name      string  `json:"name" gorm:"not null;index"`
+	State     string  `json:"state"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	RegionID *uint   `json:"region_id" gorm:"index"`
+	Region   *Region `json:"region,omitempty"`
+
+	Timezone   string      `json:"timezone"`
+	Currency   string      `json:"currency"`
+	TaxProfile *TaxProfile `json:"tax_profile" gorm:"serializer:json"`
+	// Disabled cities and their venues are hidden from every listing
+	Enabled bool `json:"enabled" gorm:"default:true"`
+}
+
+// CitySettings is a city's configuration after falling back to its region
+type CitySettings struct {
+	Timezone   string      `json:"timezone"`
+	Currency   string      `json:"currency"`
+	TaxProfile *TaxProfile `json:"tax_profile"`
+}
+
+// Settings works out the city's timezone, currency and tax profile. The region must be loaded to fall back to it.
+func (c City) Settings() CitySettings {
+	settings := CitySettings{Timezone: c.Timezone, Currency: c.Currency, TaxProfile: c.TaxProfile}
+	if c.Region != nil {
+		if settings.Timezone == "" {
+			settings.Timezone = c.Region.Timezone
+		}
+		if settings.Currency == "" {
+			settings.Currency = c.Region.Currency
+		}
+		if settings.TaxProfile == nil {
+			settings.TaxProfile = c.Region.TaxProfile
+		}
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = "UTC"
+	}
+	return settings
+}
+
+// IsAvailable reports whether the city and its region are open. The region must be loaded.
+func (c City) IsAvailable() bool {
+	return c.Enabled && (c.Region == nil || c.Region.Enabled)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestCity_SettingsFallBackToRegion(t *testing.T) {
+	gst := &TaxProfile{Name: "GST", Rate: 18, Inclusive: true}
+	region := &Region{Timezone: "Asia/Kolkata", Currency: "INR", TaxProfile: gst, Enabled: true}
+
+	settings := City{Region: region}.Settings()
+	if settings.Timezone != "Asia/Kolkata" || settings.Currency != "INR" || settings.TaxProfile != gst {
+		t.Errorf("expected the region's settings, got %+v", settings)
+	}
+
+	vat := &TaxProfile{Name: "VAT", Rate: 5}
+	settings = City{Region: region, Currency: "USD", TaxProfile: vat}.Settings()
+	if settings.Currency != "USD" || settings.TaxProfile != vat || settings.Timezone != "Asia/Kolkata" {
+		t.Errorf("expected the city's own currency and tax, got %+v", settings)
+	}
+
+	if settings := (City{}).Settings(); settings.Timezone != "UTC" {
+		t.Errorf("expected UTC without a timezone, got %q", settings.Timezone)
+	}
+}
+
+func TestCity_IsAvailable(t *testing.T) {
+	if !(City{Enabled: true}).IsAvailable() {
+		t.Error("an enabled city without a region should be available")
+	}
+	if (City{Enabled: false}).IsAvailable() {
+		t.Error("a disabled city should not be available")
+	}
+	if (City{Enabled: true, Region: &Region{Enabled: false}}).IsAvailable() {
+		t.Error("a city in a disabled region should not be available")
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Identity links a user to their account at an OpenID Connect provider, so they can sign in with it.
+// Subject is the provider's id for the account; the email is only what it was when last seen.
+type Identity struct {
+	gorm.Model
+	UserID      uint       `json:"-" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"not null;uniqueIndex:idx_identity_subject"`
+	Subject     string     `json:"-" gorm:"not null;uniqueIndex:idx_identity_subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCLogin is a sign-in or link started with a provider and waiting for the user to come back.
+// The state sent to the provider is stored as a hash; the nonce and PKCE verifier are needed to finish.
+type OIDCLogin struct {
+	gorm.Model
+	Provider     string    `gorm:"not null"`
+	StateHash    string    `gorm:"not null;uniqueIndex"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	UserID       *uint     // Set when linking the provider to a signed-in user
+	ExpiresAt    time.Time `gorm:"index"`
+	UsedAt       *time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import "gorm.io/gorm"
+
+// Kinds of movie media
+const (
+	MediaPoster   = "poster"
+	MediaBackdrop = "backdrop"
+	MediaStill    = "still"
+	MediaTrailer  = "trailer"
+)
+
+var MediaTypes = []string{MediaPoster, MediaBackdrop, MediaStill, MediaTrailer}
+
+// MovieMedia is one image or video link in a movie's gallery
+type MovieMedia struct {
+	gorm.Model
+	MovieID uint   `json:"movie_id" gorm:"not null;index"`
+	Type    string `json:"type" gorm:"not null"`
+	URL     string `json:"url" gorm:"not null"`
+	Size    string `json:"size"`   // thumbnail, card, hero or original - empty for trailers
+	Format  string `json:"format"` // jpeg or webp for processed uploads, empty for links
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	// Variants made from the same upload share a key
+	UploadKey string `json:"upload_key" gorm:"index"`
+	// Where an uploaded file lives in the blob store, empty for links
+	StorageKey string `json:"-"`
+	Language   string `json:"language"` // ISO 639-1 code, empty when it has no text or audio
+	SortOrder  int    `json:"sort_order"`
+	// One primary per type, size and format; the primary poster and trailer are mirrored on Movie
+	IsPrimary bool `json:"is_primary"`
+}
@@ -1,9 +1,22 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Lifecycle stages a movie moves through, driven by its release window
+const (
+	StageAnnounced  = "announced"   // Listed, booking not open yet
+	StagePreBooking = "pre_booking" // Booking open ahead of release
+	StageNowShowing = "now_showing" // Released and in theatres
+	StageArchived   = "archived"    // Run has ended
+)
+
+var MovieStages = []string{StageAnnounced, StagePreBooking, StageNowShowing, StageArchived}
+
 type Movie struct {
 	gorm.Model
 	Title       string `json:"title" gorm:"not null" validate:"required,min=2,max=50"`
@@ -11,6 +24,34 @@ type Movie struct {
 	Duration    string `json:"duration" gorm:"not null"` //in hours
 	Poster      string `json:"poster"`                   //to store image url
 
+	// URLs of the primary poster's processed variants, size -> format -> URL,
+	// e.g. {"card": {"jpeg": "...", "webp": "..."}}. Empty when the poster is a plain link.
+	PosterVariants map[string]map[string]string `json:"poster_variants,omitempty" gorm:"serializer:json"`
+
+	// Stored as JSON text, used for filtering and recommendations
+	Genres    []string `json:"genres" gorm:"serializer:json"`
+	Languages []string `json:"languages" gorm:"serializer:json"`
+	Cast      []string `json:"cast" gorm:"serializer:json"`
+
+	TrailerURL string `json:"trailer_url"`
+
+	// Link to an external metadata provider. The snapshot holds the values last pulled
+	// from the provider, so a re-sync can tell which fields an admin has since edited.
+	ExternalID       string            `json:"external_id" gorm:"index"`
+	MetadataSource   string            `json:"metadata_source"`
+	MetadataSyncedAt *time.Time        `json:"metadata_synced_at"`
+	MetadataSnapshot map[string]string `json:"-" gorm:"serializer:json"`
+
+	// Release window - nil dates mean "no restriction" so older movies stay now showing
+	PreBookingAt *time.Time `json:"pre_booking_at"`
+	ReleaseDate  *time.Time `json:"release_date"`
+	EndDate      *time.Time `json:"end_date"`
+
+	// Admin override, wins over the dates when set
+	StatusOverride string `json:"status_override"`
+	// Computed after every load, never stored
+	Status string `json:"status" gorm:"-"`
+
 	//Many movies will be associated with multiple venues
 	//Movie -> Venue (Many to Many)
 
@@ -18,4 +59,80 @@ type Movie struct {
 
 	//One movie can have multiple show timings
 	ShowTimes []ShowTime `json:"show_times"`
+
+	Credits []MovieCredit `json:"credits,omitempty"`
+	Media   []MovieMedia  `json:"media,omitempty"`
+}
+
+// LifecycleStage works out where the movie is at the given time
+func (m *Movie) LifecycleStage(now time.Time) string {
+	if m.StatusOverride != "" {
+		return m.StatusOverride
+	}
+	if m.EndDate != nil && m.EndDate.Before(now) {
+		return StageArchived
+	}
+	if m.ReleaseDate == nil || !m.ReleaseDate.After(now) {
+		return StageNowShowing
+	}
+	if m.PreBookingAt != nil && !m.PreBookingAt.After(now) {
+		return StagePreBooking
+	}
+	return StageAnnounced
+}
+
+// IsBookable reports whether showtimes of this movie can be reserved
+func (m *Movie) IsBookable(now time.Time) bool {
+	stage := m.LifecycleStage(now)
+	return stage == StagePreBooking || stage == StageNowShowing
+}
+
+func (m *Movie) AfterFind(tx *gorm.DB) error {
+	m.Status = m.LifecycleStage(time.Now())
+	return nil
+}
+
+// MovieStageScope filters a movie query down to the given lifecycle stages, mirroring LifecycleStage in SQL
+func MovieStageScope(now time.Time, stages ...string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		var conditions []string
+		for _, stage := range stages {
+			var byDates string
+			switch stage {
+			case StageArchived:
+				byDates = "movies.end_date IS NOT NULL AND movies.end_date < @now"
+			case StageNowShowing:
+				byDates = "(movies.end_date IS NULL OR movies.end_date >= @now) AND (movies.release_date IS NULL OR movies.release_date <= @now)"
+			case StagePreBooking:
+				byDates = "(movies.end_date IS NULL OR movies.end_date >= @now) AND movies.release_date > @now AND movies.pre_booking_at IS NOT NULL AND movies.pre_booking_at <= @now"
+			case StageAnnounced:
+				byDates = "(movies.end_date IS NULL OR movies.end_date >= @now) AND movies.release_date > @now AND (movies.pre_booking_at IS NULL OR movies.pre_booking_at > @now)"
+			default:
+				continue
+			}
+			conditions = append(conditions, "movies.status_override = '"+stage+"' OR (COALESCE(movies.status_override, '') = '' AND ("+byDates+"))")
+		}
+		if len(conditions) == 0 {
+			return db
+		}
+		return db.Where("(("+strings.Join(conditions, ") OR (")+"))", map[string]interface{}{"now": now})
+	}
+}
+
+// MovieInterest is a user asking to be told when booking opens for a movie
+type MovieInterest struct {
+	gorm.Model
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_movie_interest_user_movie"`
+	MovieID    uint       `json:"movie_id" gorm:"not null;uniqueIndex:idx_movie_interest_user_movie"`
+	NotifiedAt *time.Time `json:"notified_at"`
+}
+
+// MovieCredit is a person on a movie, pulled from the metadata provider
+type MovieCredit struct {
+	gorm.Model
+	MovieID   uint   `json:"movie_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null"`
+	Role      string `json:"role" gorm:"not null"`
+	Character string `json:"character"`
+	SortOrder int    `json:"sort_order"`
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMovieLifecycleStage(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	cases := []struct {
+		name  string
+		movie Movie
+		want  string
+	}{
+		{"no dates is now showing", Movie{}, StageNowShowing},
+		{"released", Movie{ReleaseDate: at(-day)}, StageNowShowing},
+		{"future release", Movie{ReleaseDate: at(10 * day)}, StageAnnounced},
+		{"pre-booking not yet open", Movie{PreBookingAt: at(day), ReleaseDate: at(10 * day)}, StageAnnounced},
+		{"pre-booking open", Movie{PreBookingAt: at(-day), ReleaseDate: at(10 * day)}, StagePreBooking},
+		{"run ended", Movie{ReleaseDate: at(-30 * day), EndDate: at(-day)}, StageArchived},
+		{"override wins", Movie{ReleaseDate: at(-day), StatusOverride: StageArchived}, StageArchived},
+	}
+	for _, tc := range cases {
+		if got := tc.movie.LifecycleStage(now); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMovieIsBookable(t *testing.T) {
+	now := time.Now()
+	future := now.Add(48 * time.Hour)
+	announced := Movie{ReleaseDate: &future}
+	if announced.IsBookable(now) {
+		t.Error("announced movie should not be bookable")
+	}
+	preBooking := Movie{ReleaseDate: &future, StatusOverride: StagePreBooking}
+	if !preBooking.IsBookable(now) {
+		t.Error("pre-booking movie should be bookable")
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification is a message sent to a user, kept so we have a record of what went out
+type Notification struct {
+	gorm.Model
+	UserID  uint       `json:"user_id" gorm:"not null;index"`
+	Channel string     `json:"channel" gorm:"not null"` // email, sms, push
+	Subject string     `json:"subject"`
+	Body    string     `json:"body"`
+	SentAt  *time.Time `json:"sent_at"`
+}
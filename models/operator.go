@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Branding is how an operator's venues are presented
+type Branding struct {
+	LogoURL      string `json:"logo_url" validate:"omitempty,url"`
+	PrimaryColor string `json:"primary_color" validate:"omitempty,hexcolor"`
+	Website      string `json:"website" validate:"omitempty,url"`
+	Tagline      string `json:"tagline" validate:"max=140"`
+}
+
+// PricingDefaults apply to showtimes at the operator's venues
+type PricingDefaults struct {
+	SeatPrice      float32 `json:"seat_price" validate:"min=0"`      // Per seat for new showtimes, 0 keeps the standard price
+	ConvenienceFee float32 `json:"convenience_fee" validate:"min=0"` // Per ticket
+}
+
+// PayoutSettings is where and how often the operator is paid for ticket sales
+type PayoutSettings struct {
+	AccountName       string  `json:"account_name"`
+	AccountNumber     string  `json:"account_number"`
+	BankCode          string  `json:"bank_code"` // IFSC, sort code or routing number
+	Schedule          string  `json:"schedule" validate:"omitempty,oneof=weekly monthly"`
+	CommissionPercent float64 `json:"commission_percent" validate:"min=0,max=100"` // Kept by the platform
+}
+
+// Masked returns the settings with all but the last four digits of the account number hidden
+func (p PayoutSettings) Masked() PayoutSettings {
+	if n := len(p.AccountNumber); n > 4 {
+		p.AccountNumber = strings.Repeat("*", n-4) + p.AccountNumber[n-4:]
+	}
+	return p
+}
+
+// Operator is a cinema chain that owns venues, e.g. PVR or INOX.
+// Its admins manage only its venues, and its defaults fill in for venues that don't set their own.
+type Operator struct {
+	gorm.Model
+	Name            string              `json:"name" gorm:"not null;uniqueIndex"`
+	Branding        Branding            `json:"branding" gorm:"serializer:json"`
+	PricingDefaults PricingDefaults     `json:"pricing_defaults" gorm:"serializer:json"`
+	RefundPolicy    *CancellationPolicy `json:"refund_policy" gorm:"serializer:json"`
+	// Only shown to the operator's own admins, see controllers.GetOperatorByID
+	PayoutSettings PayoutSettings `json:"-" gorm:"serializer:json"`
+}
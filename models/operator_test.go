@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func TestUser_AdminScopes(t *testing.T) {
+	pvr, inox := uint(1), uint(2)
+	var super, operatorAdmin, customer User
+	super.SetRoles([]RoleAssignment{{Role: RoleSuperAdmin}})
+	operatorAdmin.SetRoles([]RoleAssignment{{Role: RoleOperatorAdmin, OperatorID: &pvr}})
+
+	if !super.IsSuperAdmin() || operatorAdmin.IsSuperAdmin() || customer.IsSuperAdmin() {
+		t.Error("only a global super admin role makes a super admin")
+	}
+
+	ownVenue, otherVenue, independent := Venue{OperatorID: &pvr}, Venue{OperatorID: &inox}, Venue{}
+	if !super.CanForVenue(PermManageVenues, otherVenue) || !super.CanForVenue(PermManageVenues, independent) {
+		t.Error("a super admin should manage every venue")
+	}
+	if !operatorAdmin.CanForVenue(PermManageVenues, ownVenue) {
+		t.Error("an operator admin should manage the operator's venues")
+	}
+	if operatorAdmin.CanForVenue(PermManageVenues, otherVenue) || operatorAdmin.CanForVenue(PermManageVenues, independent) {
+		t.Error("an operator admin should not manage other venues")
+	}
+	if customer.CanForVenue(PermManageVenues, ownVenue) || customer.CanForOperator(PermManageOperator, pvr) {
+		t.Error("a customer should manage nothing")
+	}
+	if !operatorAdmin.CanForOperator(PermManageOperator, pvr) || operatorAdmin.CanForOperator(PermManageOperator, inox) {
+		t.Error("an operator admin should manage only their own operator")
+	}
+}
+
+func TestVenue_PolicyFallsBackToOperator(t *testing.T) {
+	chainPolicy := &CancellationPolicy{AllowCancellation: true, CutoffMinutes: 120, RefundPercent: 50}
+	operator := &Operator{RefundPolicy: chainPolicy, PricingDefaults: PricingDefaults{SeatPrice: 250}}
+
+	if got := (Venue{Operator: operator}).Policy(); got != chainPolicy {
+		t.Errorf("expected the operator's policy, got %+v", got)
+	}
+	own := &CancellationPolicy{Text: "No refunds"}
+	if got := (Venue{Operator: operator, CancellationPolicy: own}).Policy(); got != own {
+		t.Errorf("expected the venue's own policy, got %+v", got)
+	}
+	if (Venue{}).Policy() != nil {
+		t.Error("expected no policy without an operator")
+	}
+
+	if price := (Venue{Operator: operator}).SeatPrice(); price != 250 {
+		t.Errorf("expected the operator's seat price, got %v", price)
+	}
+	if price := (Venue{}).SeatPrice(); price != 0 {
+		t.Errorf("expected the standard price without an operator, got %v", price)
+	}
+}
+
+func TestPayoutSettings_Masked(t *testing.T) {
+	p := PayoutSettings{AccountName: "PVR Ltd", AccountNumber: "001234567890"}
+	if got := p.Masked().AccountNumber; got != "********7890" {
+		t.Errorf("expected the account number masked, got %q", got)
+	}
+	if p.AccountNumber != "001234567890" {
+		t.Error("Masked should not change the original")
+	}
+	if got := (PayoutSettings{AccountNumber: "1234"}).Masked().AccountNumber; got != "1234" {
+		t.Errorf("short numbers have nothing to hide, got %q", got)
+	}
+}
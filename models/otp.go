@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// What an OTP was sent for
+const (
+	OTPPurposeLogin       = "login"
+	OTPPurposeVerifyPhone = "verify_phone"
+)
+
+// OTPChallenge records a code sent to a phone, so resends and wrong guesses can be limited
+// whichever provider sends the code. The code itself stays with the provider.
+type OTPChallenge struct {
+	gorm.Model
+	Phone      string `gorm:"not null;index"`
+	Purpose    string `gorm:"not null"`
+	UserID     uint   `gorm:"index"`
+	Attempts   int
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time // Set once the right code was entered
+}
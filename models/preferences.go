@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Seat categories a user can prefer. Seats are priced per showtime rather than labelled, so a category
+// picks a price tier: the cheapest seats, the middle ones or the dearest.
+const (
+	SeatBudget   = "budget"
+	SeatStandard = "standard"
+	SeatPremium  = "premium"
+)
+
+var SeatCategories = []string{SeatBudget, SeatStandard, SeatPremium}
+
+// Channels notifications can go out on
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+var NotificationChannels = []string{ChannelEmail, ChannelSMS, ChannelPush}
+
+const maxPreferenceTags = 20
+
+var (
+	ErrUnknownSeatCategory = errors.New("seat_category must be one of budget, standard, premium")
+	ErrUnknownChannel      = errors.New("notification_channels can only hold email, sms and push")
+	ErrTooManyPreferences  = errors.New("too many languages or genres")
+)
+
+// Preferences are what the user told us they like. Listings, recommendations, seat layouts and notifications
+// use them when a request doesn't say otherwise.
+type Preferences struct {
+	Languages            []string `json:"languages"`
+	Genres               []string `json:"genres"`
+	SeatCategory         string   `json:"seat_category,omitempty"`
+	NotificationChannels []string `json:"notification_channels"` // Empty means email
+}
+
+// Normalize trims and dedupes the lists and checks the rest against the known values
+func (p *Preferences) Normalize() error {
+	p.Languages = cleanTags(p.Languages)
+	p.Genres = cleanTags(p.Genres)
+	if len(p.Languages) > maxPreferenceTags || len(p.Genres) > maxPreferenceTags {
+		return ErrTooManyPreferences
+	}
+	p.SeatCategory = strings.ToLower(strings.TrimSpace(p.SeatCategory))
+	if p.SeatCategory != "" && !slices.Contains(SeatCategories, p.SeatCategory) {
+		return ErrUnknownSeatCategory
+	}
+	channels := cleanTags(p.NotificationChannels)
+	for i, ch := range channels {
+		channels[i] = strings.ToLower(ch)
+		if !slices.Contains(NotificationChannels, channels[i]) {
+			return ErrUnknownChannel
+		}
+	}
+	p.NotificationChannels = channels
+	return nil
+}
+
+// cleanTags drops blanks and case-insensitive duplicates, keeping the first spelling of each
+func cleanTags(tags []string) []string {
+	cleaned := []string{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t != "" && !slices.ContainsFunc(cleaned, func(c string) bool { return strings.EqualFold(c, t) }) {
+			cleaned = append(cleaned, t)
+		}
+	}
+	return cleaned
+}
+
+// Channels are where the user's notifications go. Texts need a verified phone number, and there is
+// always at least email.
+func (u User) Channels() []string {
+	var channels []string
+	for _, ch := range u.Preferences.NotificationChannels {
+		if ch == ChannelSMS && u.PhoneVerifiedAt == nil {
+			continue
+		}
+		channels = append(channels, ch)
+	}
+	if len(channels) == 0 {
+		return []string{ChannelEmail}
+	}
+	return channels
+}
+
+// SeatCategoryPrice is the price of the category's tier among the seat prices, false if there are no seats
+// or no category. With two prices standard is the cheaper one.
+func SeatCategoryPrice(prices []float32, category string) (float32, bool) {
+	tiers := slices.Clone(prices)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i] < tiers[j] })
+	tiers = slices.Compact(tiers)
+	if len(tiers) == 0 {
+		return 0, false
+	}
+	switch category {
+	case SeatBudget:
+		return tiers[0], true
+	case SeatStandard:
+		return tiers[(len(tiers)-1)/2], true
+	case SeatPremium:
+		return tiers[len(tiers)-1], true
+	}
+	return 0, false
+}
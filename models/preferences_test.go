@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPreferences_Normalize(t *testing.T) {
+	p := Preferences{
+		Languages:            []string{" Hindi", "english", "hindi", ""},
+		Genres:               []string{"Sci-Fi"},
+		SeatCategory:         " Premium ",
+		NotificationChannels: []string{"SMS", "email", "sms"},
+	}
+	if err := p.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Languages, []string{"Hindi", "english"}) {
+		t.Errorf("languages not cleaned: %v", p.Languages)
+	}
+	if p.SeatCategory != SeatPremium {
+		t.Errorf("seat category not normalized: %q", p.SeatCategory)
+	}
+	if !slices.Equal(p.NotificationChannels, []string{"sms", "email"}) {
+		t.Errorf("channels not cleaned: %v", p.NotificationChannels)
+	}
+
+	if err := (&Preferences{SeatCategory: "balcony"}).Normalize(); !errors.Is(err, ErrUnknownSeatCategory) {
+		t.Errorf("expected ErrUnknownSeatCategory, got %v", err)
+	}
+	if err := (&Preferences{NotificationChannels: []string{"pigeon"}}).Normalize(); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected ErrUnknownChannel, got %v", err)
+	}
+}
+
+func TestUser_Channels(t *testing.T) {
+	var u User
+	if !slices.Equal(u.Channels(), []string{ChannelEmail}) {
+		t.Errorf("expected email by default, got %v", u.Channels())
+	}
+	u.Preferences.NotificationChannels = []string{ChannelSMS}
+	if !slices.Equal(u.Channels(), []string{ChannelEmail}) {
+		t.Errorf("texts need a verified phone, got %v", u.Channels())
+	}
+	now := time.Now()
+	u.PhoneVerifiedAt = &now
+	u.Preferences.NotificationChannels = []string{ChannelSMS, ChannelPush}
+	if !slices.Equal(u.Channels(), []string{ChannelSMS, ChannelPush}) {
+		t.Errorf("expected sms and push, got %v", u.Channels())
+	}
+}
+
+func TestSeatCategoryPrice(t *testing.T) {
+	prices := []float32{250, 150, 400, 250, 150}
+	for category, want := range map[string]float32{SeatBudget: 150, SeatStandard: 250, SeatPremium: 400} {
+		if got, ok := SeatCategoryPrice(prices, category); !ok || got != want {
+			t.Errorf("%s: expected %v, got %v (%v)", category, want, got, ok)
+		}
+	}
+	if got, _ := SeatCategoryPrice([]float32{300, 150}, SeatStandard); got != 150 {
+		t.Errorf("standard of two tiers should be the cheaper, got %v", got)
+	}
+	if _, ok := SeatCategoryPrice(nil, SeatBudget); ok {
+		t.Error("no seats should have no price")
+	}
+	if _, ok := SeatCategoryPrice(prices, ""); ok {
+		t.Error("no category should have no price")
+	}
+}
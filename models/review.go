@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review moderation states
+const (
+	ReviewPublished = "published"
+	ReviewHidden    = "hidden"
+)
+
+type Review struct {
+	gorm.Model
+	UserID  uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_review_user_movie"`
+	MovieID uint   `json:"movie_id" gorm:"not null;uniqueIndex:idx_review_user_movie;index"`
+	Rating  int    `json:"rating" gorm:"not null"` // 1 to 10
+	Body    string `json:"body"`
+
+	// Set when the reviewer has an order for this movie
+	IsVerified   bool `json:"is_verified"`
+	HelpfulCount int  `json:"helpful_count"`
+
+	Status         string     `json:"status" gorm:"not null;default:published;index"`
+	ModeratedByID  *uint      `json:"-"`
+	ModeratedAt    *time.Time `json:"moderated_at,omitempty"`
+	ModerationNote string     `json:"moderation_note,omitempty"`
+}
+
+// ReviewVote is one user marking a review as helpful
+type ReviewVote struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	ReviewID  uint `gorm:"not null;uniqueIndex:idx_review_vote"`
+	UserID    uint `gorm:"not null;uniqueIndex:idx_review_vote"`
+}
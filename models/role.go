@@ -0,0 +1,223 @@
+package models
+
+import (
+	"errors"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// Roles a user can hold. Everyone is a customer; the others are granted with a RoleAssignment.
+const (
+	RoleSuperAdmin    = "super_admin"
+	RoleOperatorAdmin = "operator_admin"
+	RoleVenueManager  = "venue_manager"
+	RoleBoxOffice     = "box_office"
+	RoleSupport       = "support"
+	RoleCustomer      = "customer"
+)
+
+// Permissions checked by routes and handlers
+const (
+	PermManageCatalog   = "catalog:manage"   // Movies, media, metadata, catalog import and export, cities and regions
+	PermManageOperators = "operators:manage" // Create and rename operators and move venues between them
+	PermManageOperator  = "operator:manage"  // An operator's branding, defaults, payouts and reports
+	PermManageVenues    = "venues:manage"    // Create, change and delete venues
+	PermManageScreens   = "screens:manage"
+	PermManageShows     = "shows:manage" // Showtimes and the movies a venue plays
+	PermViewSales       = "sales:view"   // Orders for a venue's shows
+	PermModerateReviews = "reviews:moderate"
+	PermManageUsers     = "users:manage" // Look up users, their orders, disable them, sign them out and force password resets
+	PermViewAudit       = "audit:view"   // The log of what staff did to users' accounts
+	PermManageRoles     = "roles:manage" // Grant and revoke roles within the holder's scope
+)
+
+// RoleScope says where a role can be granted: everywhere, over one operator's venues or over one venue
+type RoleScope struct {
+	Global   bool `json:"global"`
+	Operator bool `json:"operator"`
+	Venue    bool `json:"venue"`
+}
+
+// RoleDefinition is what a role allows and where it can be granted
+type RoleDefinition struct {
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	Scope       RoleScope `json:"scope"`
+}
+
+// Roles lists every role that can be granted, in order of reach
+var Roles = []RoleDefinition{
+	{RoleSuperAdmin, []string{PermManageCatalog, PermManageOperators, PermManageOperator, PermManageVenues, PermManageScreens,
+		PermManageShows, PermViewSales, PermModerateReviews, PermManageUsers, PermViewAudit, PermManageRoles}, RoleScope{Global: true}},
+	{RoleOperatorAdmin, []string{PermManageOperator, PermManageVenues, PermManageScreens, PermManageShows, PermViewSales,
+		PermManageRoles}, RoleScope{Operator: true}},
+	{RoleVenueManager, []string{PermManageScreens, PermManageShows, PermViewSales}, RoleScope{Operator: true, Venue: true}},
+	{RoleBoxOffice, []string{PermViewSales}, RoleScope{Operator: true, Venue: true}},
+	{RoleSupport, []string{PermViewSales, PermModerateReviews, PermManageUsers, PermViewAudit}, RoleScope{Global: true}},
+}
+
+// Roles a holder of PermManageRoles without a global role may hand out, within their own scope
+var delegableRoles = []string{RoleVenueManager, RoleBoxOffice}
+
+// LookupRole returns the definition of a grantable role
+func LookupRole(name string) (RoleDefinition, bool) {
+	i := slices.IndexFunc(Roles, func(r RoleDefinition) bool { return r.Name == name })
+	if i < 0 {
+		return RoleDefinition{}, false
+	}
+	return Roles[i], true
+}
+
+var (
+	ErrUnknownRole  = errors.New("unknown role")
+	ErrInvalidScope = errors.New("role can't be granted with this scope")
+)
+
+// RoleAssignment grants a user a role everywhere, or over one operator's venues, or over one venue
+type RoleAssignment struct {
+	gorm.Model
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	Role        string `json:"role" gorm:"not null"`
+	OperatorID  *uint  `json:"operator_id,omitempty" gorm:"index"`
+	VenueID     *uint  `json:"venue_id,omitempty" gorm:"index"`
+	GrantedByID *uint  `json:"granted_by_id,omitempty"`
+}
+
+// Validate checks the role exists and is scoped the way it allows
+func (a RoleAssignment) Validate() error {
+	def, ok := LookupRole(a.Role)
+	if !ok {
+		return ErrUnknownRole
+	}
+	switch {
+	case a.OperatorID != nil && a.VenueID != nil:
+		return ErrInvalidScope
+	case a.OperatorID != nil && !def.Scope.Operator, a.VenueID != nil && !def.Scope.Venue:
+		return ErrInvalidScope
+	case a.OperatorID == nil && a.VenueID == nil && !def.Scope.Global:
+		return ErrInvalidScope
+	}
+	return nil
+}
+
+// SameGrant reports whether both assignments give the same role over the same scope
+func (a RoleAssignment) SameGrant(b RoleAssignment) bool {
+	return a.Role == b.Role && equalID(a.OperatorID, b.OperatorID) && equalID(a.VenueID, b.VenueID)
+}
+
+func equalID(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// Grants reports whether the role includes the permission, whatever its scope
+func (a RoleAssignment) Grants(perm string) bool {
+	def, ok := LookupRole(a.Role)
+	return ok && slices.Contains(def.Permissions, perm)
+}
+
+// IsGlobal reports whether the assignment isn't limited to an operator or venue
+func (a RoleAssignment) IsGlobal() bool {
+	return a.OperatorID == nil && a.VenueID == nil
+}
+
+// coversOperator reports whether the assignment applies to everything the operator owns
+func (a RoleAssignment) coversOperator(operatorID uint) bool {
+	return a.IsGlobal() || (a.OperatorID != nil && *a.OperatorID == operatorID)
+}
+
+// coversVenue reports whether the assignment applies to the venue, directly or through its operator
+func (a RoleAssignment) coversVenue(v Venue) bool {
+	return a.IsGlobal() || (v.OperatorID != nil && a.coversOperator(*v.OperatorID)) || (a.VenueID != nil && *a.VenueID == v.ID)
+}
+
+// SetRoles records the user's role assignments, which the permission checks below go by
+func (u *User) SetRoles(roles []RoleAssignment) {
+	u.Roles = roles
+	u.IsAdmin = len(roles) > 0
+}
+
+// RoleNames returns the user's roles, customer when they hold none
+func (u User) RoleNames() []string {
+	if len(u.Roles) == 0 {
+		return []string{RoleCustomer}
+	}
+	var names []string
+	for _, a := range u.Roles {
+		if !slices.Contains(names, a.Role) {
+			names = append(names, a.Role)
+		}
+	}
+	return names
+}
+
+// HasRole reports whether the user holds the role anywhere
+func (u User) HasRole(role string) bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Role == role })
+}
+
+// IsSuperAdmin reports whether the user can do anything anywhere
+func (u User) IsSuperAdmin() bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Role == RoleSuperAdmin && a.IsGlobal() })
+}
+
+// IsStaff reports whether the user holds any role beyond customer
+func (u User) IsStaff() bool {
+	return len(u.Roles) > 0
+}
+
+// Can reports whether the user has the permission anywhere. Routes check this; handlers then check the scope.
+func (u User) Can(perm string) bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Grants(perm) })
+}
+
+// CanGlobally reports whether the user has the permission over everything
+func (u User) CanGlobally(perm string) bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Grants(perm) && a.IsGlobal() })
+}
+
+// CanForOperator reports whether the user has the permission over the operator and all its venues
+func (u User) CanForOperator(perm string, operatorID uint) bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Grants(perm) && a.coversOperator(operatorID) })
+}
+
+// CanForVenue reports whether the user has the permission over the venue
+func (u User) CanForVenue(perm string, v Venue) bool {
+	return slices.ContainsFunc(u.Roles, func(a RoleAssignment) bool { return a.Grants(perm) && a.coversVenue(v) })
+}
+
+// OperatorsWith returns the operators the user has the permission over through an operator-scoped role
+func (u User) OperatorsWith(perm string) []uint {
+	var ids []uint
+	for _, a := range u.Roles {
+		if a.Grants(perm) && a.OperatorID != nil && !slices.Contains(ids, *a.OperatorID) {
+			ids = append(ids, *a.OperatorID)
+		}
+	}
+	return ids
+}
+
+// CanGrant reports whether the user may grant or revoke the assignment. Super admins can grant anything.
+// Others with PermManageRoles can hand out venue manager and box office roles within their own scope;
+// venue is the assignment's venue, if it has one.
+func (u User) CanGrant(a RoleAssignment, venue *Venue) bool {
+	if u.IsSuperAdmin() {
+		return true
+	}
+	if !slices.Contains(delegableRoles, a.Role) {
+		return false
+	}
+	switch {
+	case a.OperatorID != nil:
+		return u.CanForOperator(PermManageRoles, *a.OperatorID)
+	case venue != nil:
+		return u.CanForVenue(PermManageRoles, *venue)
+	}
+	return false
+}
+
+// CanManageAccount reports whether the user may disable, enable or force a password reset on the target's account.
+// Nobody can lock themselves out, and only super admins can act on other staff.
+func (u User) CanManageAccount(target User) bool {
+	return u.ID != target.ID && (u.IsSuperAdmin() || !target.IsStaff())
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRoleAssignment_Validate(t *testing.T) {
+	id := uint(7)
+	valid := []RoleAssignment{
+		{Role: RoleSuperAdmin},
+		{Role: RoleSupport},
+		{Role: RoleOperatorAdmin, OperatorID: &id},
+		{Role: RoleVenueManager, OperatorID: &id},
+		{Role: RoleVenueManager, VenueID: &id},
+		{Role: RoleBoxOffice, VenueID: &id},
+	}
+	for _, a := range valid {
+		if err := a.Validate(); err != nil {
+			t.Errorf("%s %v/%v: unexpected error %v", a.Role, a.OperatorID, a.VenueID, err)
+		}
+	}
+	invalid := []RoleAssignment{
+		{Role: RoleSuperAdmin, OperatorID: &id},
+		{Role: RoleOperatorAdmin},
+		{Role: RoleOperatorAdmin, VenueID: &id},
+		{Role: RoleBoxOffice},
+		{Role: RoleVenueManager, OperatorID: &id, VenueID: &id},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("%s %v/%v: expected ErrInvalidScope, got %v", a.Role, a.OperatorID, a.VenueID, err)
+		}
+	}
+	for _, role := range []string{"", RoleCustomer, "owner"} {
+		if err := (RoleAssignment{Role: role}).Validate(); !errors.Is(err, ErrUnknownRole) {
+			t.Errorf("%q: expected ErrUnknownRole, got %v", role, err)
+		}
+	}
+}
+
+func TestUser_VenueScopedRoles(t *testing.T) {
+	pvr, inox := uint(1), uint(2)
+	venue := Venue{OperatorID: &pvr}
+	venue.ID = 10
+	sibling := Venue{OperatorID: &pvr}
+	sibling.ID = 11
+	elsewhere := Venue{OperatorID: &inox}
+	elsewhere.ID = 12
+
+	var manager, boxOffice, support User
+	manager.SetRoles([]RoleAssignment{{Role: RoleVenueManager, VenueID: &venue.ID}})
+	boxOffice.SetRoles([]RoleAssignment{{Role: RoleBoxOffice, OperatorID: &pvr}})
+	support.SetRoles([]RoleAssignment{{Role: RoleSupport}})
+
+	if !manager.CanForVenue(PermManageShows, venue) || manager.CanForVenue(PermManageShows, sibling) {
+		t.Error("a venue manager should run shows at their venue only")
+	}
+	if manager.CanForVenue(PermManageVenues, venue) {
+		t.Error("a venue manager should not change the venue itself")
+	}
+	if !boxOffice.CanForVenue(PermViewSales, sibling) || boxOffice.CanForVenue(PermViewSales, elsewhere) {
+		t.Error("an operator-wide box office role should cover the operator's venues only")
+	}
+	if boxOffice.Can(PermManageShows) {
+		t.Error("box office staff should not manage shows")
+	}
+	if !support.CanForVenue(PermViewSales, elsewhere) || !support.CanGlobally(PermModerateReviews) || support.Can(PermManageCatalog) {
+		t.Error("support should see sales and moderate reviews everywhere but not edit the catalog")
+	}
+	if !manager.IsAdmin || !manager.IsStaff() || (User{}).IsStaff() {
+		t.Error("holding any role should mark the user as staff")
+	}
+	if names := (User{}).RoleNames(); len(names) != 1 || names[0] != RoleCustomer {
+		t.Errorf("a user without roles should be a customer, got %v", names)
+	}
+}
+
+func TestUser_CanGrant(t *testing.T) {
+	pvr, inox := uint(1), uint(2)
+	ownVenue := Venue{OperatorID: &pvr}
+	ownVenue.ID = 10
+	otherVenue := Venue{OperatorID: &inox}
+	otherVenue.ID = 20
+
+	var super, operatorAdmin, manager User
+	super.SetRoles([]RoleAssignment{{Role: RoleSuperAdmin}})
+	operatorAdmin.SetRoles([]RoleAssignment{{Role: RoleOperatorAdmin, OperatorID: &pvr}})
+	manager.SetRoles([]RoleAssignment{{Role: RoleVenueManager, OperatorID: &pvr}})
+
+	if !super.CanGrant(RoleAssignment{Role: RoleSuperAdmin}, nil) {
+		t.Error("a super admin should grant any role")
+	}
+	if !operatorAdmin.CanGrant(RoleAssignment{Role: RoleBoxOffice, VenueID: &ownVenue.ID}, &ownVenue) ||
+		!operatorAdmin.CanGrant(RoleAssignment{Role: RoleVenueManager, OperatorID: &pvr}, nil) {
+		t.Error("an operator admin should grant venue roles within their operator")
+	}
+	if operatorAdmin.CanGrant(RoleAssignment{Role: RoleBoxOffice, VenueID: &otherVenue.ID}, &otherVenue) ||
+		operatorAdmin.CanGrant(RoleAssignment{Role: RoleVenueManager, OperatorID: &inox}, nil) {
+		t.Error("an operator admin should not grant roles at another operator")
+	}
+	if operatorAdmin.CanGrant(RoleAssignment{Role: RoleOperatorAdmin, OperatorID: &pvr}, nil) ||
+		operatorAdmin.CanGrant(RoleAssignment{Role: RoleSupport}, nil) {
+		t.Error("an operator admin should not grant operator admin or global roles")
+	}
+	if manager.CanGrant(RoleAssignment{Role: RoleBoxOffice, VenueID: &ownVenue.ID}, &ownVenue) {
+		t.Error("a venue manager should not grant roles")
+	}
+}
+
+func TestUser_CanManageAccount(t *testing.T) {
+	var super, support, customer, otherSupport User
+	super.ID, support.ID, customer.ID, otherSupport.ID = 1, 2, 3, 4
+	super.SetRoles([]RoleAssignment{{Role: RoleSuperAdmin}})
+	support.SetRoles([]RoleAssignment{{Role: RoleSupport}})
+	otherSupport.SetRoles([]RoleAssignment{{Role: RoleSupport}})
+
+	if !support.CanManageAccount(customer) {
+		t.Error("support should manage customer accounts")
+	}
+	if support.CanManageAccount(otherSupport) || support.CanManageAccount(super) {
+		t.Error("only super admins should manage staff accounts")
+	}
+	if !super.CanManageAccount(support) {
+		t.Error("a super admin should manage staff accounts")
+	}
+	if super.CanManageAccount(super) {
+		t.Error("nobody should disable their own account")
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Projection formats a showtime can be in
+const (
+	Format2D     = "2D"
+	Format3D     = "3D"
+	FormatIMAX   = "IMAX"
+	FormatIMAX3D = "IMAX 3D"
+	Format4DX    = "4DX"
+	FormatDolby  = "Dolby Cinema"
+)
+
+var ShowFormats = []string{Format2D, Format3D, FormatIMAX, FormatIMAX3D, Format4DX, FormatDolby}
+
+// Accessibility features a screen can offer
+var AccessibilityFeatures = []string{"wheelchair", "companion_seating", "hearing_loop", "closed_captions", "audio_description"}
+
+// Screen is one auditorium in a venue. Showtimes play on a screen and get its seat capacity.
+type Screen struct {
+	gorm.Model
+	VenueID  uint   `json:"venue_id" gorm:"not null;index"`
+	Name     string `json:"name" gorm:"not null"` // e.g. "Audi 1", unique within the venue
+	Capacity int    `json:"capacity"`
+
+	// Empty means a plain 2D screen
+	Formats       []string `json:"formats" gorm:"serializer:json"`
+	Accessibility []string `json:"accessibility" gorm:"serializer:json"`
+}
+
+// SupportsFormat reports whether the screen can show the format. Every screen shows 2D.
+func (s Screen) SupportsFormat(format string) bool {
+	if format == "" || format == Format2D {
+		return true
+	}
+	for _, f := range s.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalFormat matches a format case-insensitively, e.g. "imax 3d" -> "IMAX 3D".
+// Empty means 2D; an unknown format returns false.
+func CanonicalFormat(format string) (string, bool) {
+	if strings.TrimSpace(format) == "" {
+		return Format2D, true
+	}
+	for _, f := range ShowFormats {
+		if strings.EqualFold(strings.TrimSpace(format), f) {
+			return f, true
+		}
+	}
+	return "", false
+}
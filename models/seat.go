@@ -24,12 +24,23 @@ type Seat struct {
 	ShowTime   ShowTime `json:"showtime" gorm:"foreignKey:ShowTimeID"`
 }
 
+// Order statuses
+const (
+	OrderConfirmed = "confirmed"
+	OrderRefunded  = "refunded" // The showtime was cancelled and the full price refunded
+)
+
 type Order struct {
 	gorm.Model
 	UserID     uint    `json:"user_id"`
 	ShowTimeID uint    `json:"showtime_id"`
 	TotalPrice float32 `json:"total_price"`
 
+	Status       string     `json:"status" gorm:"default:confirmed;index"`
+	RefundAmount float32    `json:"refund_amount"`
+	RefundedAt   *time.Time `json:"refunded_at"`
+	RefundReason string     `json:"refund_reason"`
+
 	// One order can have multiple seats
 	Seats []Seat `json:"seats" gorm:"many2many:order_seats;"`
 }
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one link in a login's chain of refresh tokens. Each refresh swaps it for a new one
+// in the same family; only a hash of the token is stored.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"not null;index"` // Shared by every token rotated from one login
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at"` // Set once rotated, seeing it again means the token was copied
+	RevokedAt *time.Time `json:"revoked_at"`
+
+	// The access token issued alongside, so revoking the family cuts it off too
+	AccessJTI       string    `json:"-"`
+	AccessExpiresAt time.Time `json:"-"`
+}
+
+// AccessDenial is the denylist entry that cuts off the access token issued alongside, so revoking the token
+// takes effect on the very next request. There is none when that access token has already expired.
+func (r RefreshToken) AccessDenial(now time.Time) (RevokedToken, bool) {
+	if r.AccessJTI == "" || !r.AccessExpiresAt.After(now) {
+		return RevokedToken{}, false
+	}
+	return RevokedToken{JTI: r.AccessJTI, UserID: r.UserID, ExpiresAt: r.AccessExpiresAt}, true
+}
+
+// RevokedToken is the id of an access token that is refused until it would have expired anyway
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// Session is one login on one device. Its refresh tokens share its FamilyID, and access tokens carry it as sid.
+type Session struct {
+	gorm.Model
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"not null;uniqueIndex"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"` // When its latest refresh token runs out
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// IsRevoked reports whether the session was signed out, which refuses every token it issued
+func (s Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// PasswordReset is a single-use token for setting a new password without the old one. Only its hash is stored.
+type PasswordReset struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`
+	TokenHash string     `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"index"`
+	UsedAt    *time.Time // Set once used, or when a newer reset replaces it
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshToken_AccessDenial(t *testing.T) {
+	now := time.Now()
+	live := RefreshToken{UserID: 7, AccessJTI: "jti-1", AccessExpiresAt: now.Add(time.Minute)}
+	entry, ok := live.AccessDenial(now)
+	if !ok {
+		t.Fatal("a live access token should be denylisted when its login is revoked")
+	}
+	if entry.JTI != "jti-1" || entry.UserID != 7 || !entry.ExpiresAt.Equal(live.AccessExpiresAt) {
+		t.Errorf("unexpected denylist entry: %+v", entry)
+	}
+
+	expired := RefreshToken{AccessJTI: "jti-2", AccessExpiresAt: now.Add(-time.Second)}
+	if _, ok := expired.AccessDenial(now); ok {
+		t.Error("an expired access token is refused anyway and needs no entry")
+	}
+	if _, ok := (RefreshToken{AccessExpiresAt: now.Add(time.Minute)}).AccessDenial(now); ok {
+		t.Error("a refresh token without an access token id has nothing to denylist")
+	}
+}
+
+func TestSession_IsRevoked(t *testing.T) {
+	session := Session{FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if session.IsRevoked() {
+		t.Error("a new session should not be revoked")
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	if !session.IsRevoked() {
+		t.Error("a signed out session should be revoked")
+	}
+}
@@ -1,13 +1,102 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
 	Name        string `json:"name" gorm:"not null" validate:"required,min=2,max=50"`
 	Email       string `json:"email" gorm:"not null;unique" validate:"email,required"`
 	Password    string `json:"-" gorm:"not null" validate:"required"` // Never expose in API responses
-	PhoneNumber string `json:"phone_number"` // Optional - not used when OTP is disabled
-	Otp         string `json:"-"` // Internal use only, never expose
-	IsAdmin     bool   `json:"isAdmin"`
+	PhoneNumber string `json:"phone_number" gorm:"index"` // E.164, e.g. +919876543210
+
+	// Roles beyond customer, loaded with SetRoles. IsAdmin is set when there are any, for the admin panel.
+	Roles   []RoleAssignment `json:"roles" gorm:"-"`
+	IsAdmin bool             `json:"isAdmin" gorm:"-"`
+
+	// Set for accounts created by signing in with a provider, until the user sets a password with a reset link
+	NoPassword bool `json:"no_password"`
+
+	// Set once the user confirms the phone number with a code, which lets them log in with it
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+
+	// Disabled accounts can't log in and their tokens stop working, until an admin enables them again
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+
+	// Set when the user asks to delete their account. Their personal data is scrubbed once it passes,
+	// unless they cancel first; AnonymizedAt records when that happened.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	AnonymizedAt        *time.Time `json:"-"`
+
+	// Listings are scoped to this city unless a request picks another
+	PreferredCityID *uint       `json:"preferred_city_id"`
+	Preferences     Preferences `json:"preferences" gorm:"serializer:json"`
+
+	// Nil until the user follows the link in their verification email
+	EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+	VerificationSentAt *time.Time `json:"-"`
+	// A new address the user asked to change to, which takes over once they follow the link sent to it
+	PendingEmail string `json:"pending_email,omitempty"`
+
+	// Two-factor authentication with an authenticator app. The secret is set up first, and logins
+	// ask for a code once TOTPEnabledAt is set.
+	TOTPSecret           string     `json:"-"`
+	TOTPEnabledAt        *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep         int64      `json:"-"`                        // Codes from this time step or earlier are refused
+	RecoveryCodes        []string   `json:"-" gorm:"serializer:json"` // Hashes of the unused codes
+	TwoFactorFailures    int        `json:"-"`
+	TwoFactorLockedUntil *time.Time `json:"-"`
+}
+
+// CanBook reports whether the user may reserve and book seats. Unverified users can only book
+// within grace of signing up.
+func (u User) CanBook(now time.Time, grace time.Duration) bool {
+	return u.EmailVerifiedAt != nil || now.Before(u.CreatedAt.Add(grace))
+}
+
+// IsDisabled reports whether an admin has disabled the account
+func (u User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Anonymize scrubs the personal data from the user, leaving an account nobody can sign in to. The row stays
+// so orders keep pointing at it.
+func (u *User) Anonymize(now time.Time) {
+	u.Name = "Deleted user"
+	u.Email = fmt.Sprintf("deleted-%d@deleted.invalid", u.ID)
+	u.Password = "" // Not a bcrypt hash, so no password matches it
+	u.NoPassword = true
+	u.PhoneNumber = ""
+	u.PhoneVerifiedAt = nil
+	u.PreferredCityID = nil
+	u.Preferences = Preferences{}
+	u.EmailVerifiedAt = nil
+	u.VerificationSentAt = nil
+	u.PendingEmail = ""
+	u.TOTPSecret = ""
+	u.TOTPEnabledAt = nil
+	u.TOTPLastStep = 0
+	u.RecoveryCodes = nil
+	u.TwoFactorFailures = 0
+	u.TwoFactorLockedUntil = nil
+	u.DisabledReason = ""
+	u.DeletionScheduledAt = nil
+	u.AnonymizedAt = &now
+	u.SetRoles(nil)
+}
+
+// HasTwoFactor reports whether logins ask the user for a second factor
+func (u User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// NeedsTwoFactorEnrolment reports whether a staff member has to turn on two-factor authentication before
+// their roles take effect, when the policy requires it
+func (u User) NeedsTwoFactorEnrolment(required bool) bool {
+	return required && u.IsStaff() && !u.HasTwoFactor()
 }
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUser_CanBook(t *testing.T) {
+	now := time.Now()
+	verified := User{EmailVerifiedAt: &now}
+	if !verified.CanBook(now, 0) {
+		t.Error("a verified user should be able to book")
+	}
+
+	unverified := User{}
+	unverified.CreatedAt = now.Add(-time.Hour)
+	if unverified.CanBook(now, 0) {
+		t.Error("an unverified user should not book without a grace period")
+	}
+	if !unverified.CanBook(now, 2*time.Hour) {
+		t.Error("an unverified user should book within the grace period")
+	}
+	if unverified.CanBook(now, 30*time.Minute) {
+		t.Error("an unverified user should not book once the grace period is over")
+	}
+}
+
+func TestUser_NeedsTwoFactorEnrolment(t *testing.T) {
+	now := time.Now()
+	var admin User
+	admin.SetRoles([]RoleAssignment{{Role: RoleSuperAdmin}})
+	enrolled := User{TOTPEnabledAt: &now}
+	enrolled.SetRoles(admin.Roles)
+	customer := User{}
+
+	if admin.NeedsTwoFactorEnrolment(false) {
+		t.Error("nothing is required without the policy")
+	}
+	if !admin.NeedsTwoFactorEnrolment(true) {
+		t.Error("an admin without two-factor should need to enrol under the policy")
+	}
+	if enrolled.NeedsTwoFactorEnrolment(true) || customer.NeedsTwoFactorEnrolment(true) {
+		t.Error("enrolled admins and customers need nothing more")
+	}
+}
+
+func TestUser_Anonymize(t *testing.T) {
+	now := time.Now()
+	city := uint(3)
+	u := User{
+		Name:            "Asha Rao",
+		Email:           "asha@example.com",
+		Password:        "$2a$10$hash",
+		PhoneNumber:     "+919876543210",
+		PhoneVerifiedAt: &now,
+		PreferredCityID: &city,
+		Preferences:     Preferences{Languages: []string{"Hindi"}},
+		EmailVerifiedAt: &now,
+		PendingEmail:    "asha@new.example.com",
+		TOTPSecret:      "SECRET",
+		TOTPEnabledAt:   &now,
+		RecoveryCodes:   []string{"hash"},
+	}
+	u.ID = 42
+	u.DeletionScheduledAt = &now
+	u.Anonymize(now)
+
+	if u.Name != "Deleted user" || u.Email != "deleted-42@deleted.invalid" {
+		t.Errorf("name and email not scrubbed: %q %q", u.Name, u.Email)
+	}
+	if u.Password != "" || !u.NoPassword || u.HasTwoFactor() || u.TOTPSecret != "" || u.RecoveryCodes != nil {
+		t.Error("every way to sign in should be gone")
+	}
+	if u.PhoneNumber != "" || u.PhoneVerifiedAt != nil || u.PendingEmail != "" || u.PreferredCityID != nil || len(u.Preferences.Languages) > 0 {
+		t.Error("contact details and preferences should be gone")
+	}
+	if u.AnonymizedAt == nil || u.DeletionScheduledAt != nil {
+		t.Error("expected the account marked anonymized")
+	}
+}
@@ -1,24 +1,53 @@
 package models
 
 import (
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type Venue struct {
 	gorm.Model
-	Name     string `json:"name" gorm:"not null"`
+	Name string `json:"name" gorm:"not null"`
+	// Free-text city shown to users, kept for venues created before addresses
 	Location string `json:"location" gorm:"not null"`
 
+	AddressLine1 string `json:"address_line1"`
+	AddressLine2 string `json:"address_line2"`
+	Locality     string `json:"locality"`
+	PostalCode   string `json:"postal_code"`
+
+	CityID *uint `json:"city_id" gorm:"index"`
+	City   *City `json:"city,omitempty"`
+
+	// The chain that owns the venue, nil for an independent cinema
+	OperatorID *uint     `json:"operator_id" gorm:"index"`
+	Operator   *Operator `json:"operator,omitempty"`
+
+	// Both set or both nil. Venues without coordinates never show up in nearby searches.
+	Latitude  *float64 `json:"latitude" gorm:"index:idx_venue_coordinates"`
+	Longitude *float64 `json:"longitude" gorm:"index:idx_venue_coordinates"`
+
+	Amenities          []string            `json:"amenities" gorm:"serializer:json"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy" gorm:"serializer:json"`
+	// nil means the venue hasn't published hours, so any show time is accepted
+	OperatingHours *OperatingHours `json:"operating_hours" gorm:"serializer:json"`
+
 	//Many venues will have multiple movies
 	Movies []Movie `gorm:"many2many:movie_venues;"`
 
 	//One venue can have many show timings
 	ShowTimes []ShowTime `json:"show_times"`
+
+	Screens []Screen `json:"screens,omitempty"`
 }
 
 type ShowTime struct {
 	gorm.Model
 	Timing string `json:"timing"`
+	// Set for a show on a particular date; without it Timing repeats every day
+	StartsAt *time.Time `json:"starts_at" gorm:"index"`
 
 	MovieID uint  `json:"movie_id"`
 	Movie   Movie `json:"movie"`
@@ -26,6 +55,86 @@ type ShowTime struct {
 	VenueID uint  `json:"venue_id"`
 	Venue   Venue `json:"venue"`
 
+	// Venues without screens keep their showtimes unassigned
+	ScreenID *uint   `json:"screen_id" gorm:"index"`
+	Screen   *Screen `json:"screen,omitempty"`
+	Format   string  `json:"format" gorm:"default:2D"`
+
 	//One showtime can have many seats
 	Seats []Seat `json:"seats" gorm:"foreignKey:ShowTimeID"`
 }
+
+// IsUpcoming reports whether the show still has a performance ahead of it
+func (s ShowTime) IsUpcoming(now time.Time) bool {
+	return s.StartsAt == nil || s.StartsAt.After(now)
+}
+
+// HasPlayedSince reports whether a performance has started between booked and now: a dated show once it
+// starts, a daily show once its timing first comes round after booking, in the venue's timezone loc
+func (s ShowTime) HasPlayedSince(booked, now time.Time, loc *time.Location) bool {
+	if s.StartsAt != nil {
+		return !s.StartsAt.After(now)
+	}
+	timing, err := time.Parse("15:04", s.Timing)
+	if err != nil {
+		return false
+	}
+	local := booked.In(loc)
+	first := time.Date(local.Year(), local.Month(), local.Day(), timing.Hour(), timing.Minute(), 0, 0, loc)
+	if first.Before(local) {
+		first = first.AddDate(0, 0, 1)
+	}
+	return !first.After(now)
+}
+
+// HasCoordinates reports whether the venue can be placed on a map
+func (v Venue) HasCoordinates() bool {
+	return v.Latitude != nil && v.Longitude != nil
+}
+
+// TimeLocation returns the venue's timezone from its city, or UTC. The city and its region must be loaded.
+func (v Venue) TimeLocation() *time.Location {
+	if v.City != nil {
+		if loc, err := time.LoadLocation(v.City.Settings().Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// CheckShowTime rejects a show outside the venue's operating hours. A dated show is checked
+// against that day, holidays included; a daily show against every day the venue opens.
+func (v Venue) CheckShowTime(timing string, startsAt *time.Time) error {
+	if v.OperatingHours == nil {
+		return nil
+	}
+	if startsAt != nil {
+		local := startsAt.In(v.TimeLocation())
+		if !v.OperatingHours.IsOpenAt(local) {
+			return fmt.Errorf("%w: %s is outside the venue's hours", ErrClosed, local.Format("Mon 2 Jan 15:04"))
+		}
+		return nil
+	}
+	if !v.OperatingHours.AllowsDailyShow(timing) {
+		return fmt.Errorf("%w: a daily show at %s falls outside the venue's hours on some days", ErrClosed, timing)
+	}
+	return nil
+}
+
+// Policy returns the venue's cancellation policy, or its operator's refund policy when it has none.
+// The operator must be loaded to fall back to it.
+func (v Venue) Policy() *CancellationPolicy {
+	if v.CancellationPolicy == nil && v.Operator != nil {
+		return v.Operator.RefundPolicy
+	}
+	return v.CancellationPolicy
+}
+
+// SeatPrice is the price for seats in new showtimes from the operator's defaults, 0 for the standard price.
+// The operator must be loaded.
+func (v Venue) SeatPrice() float32 {
+	if v.Operator != nil {
+		return v.Operator.PricingDefaults.SeatPrice
+	}
+	return 0
+}
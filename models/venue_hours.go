@@ -0,0 +1,174 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Venue and city timezones are looked up here, containers often lack zoneinfo
+)
+
+// Amenity tags a venue can list
+const (
+	AmenityParking         = "parking"
+	AmenityWheelchair      = "wheelchair_access"
+	AmenityFoodAndBeverage = "food_and_beverage"
+	AmenityRecliners       = "recliners"
+	AmenityMTicket         = "m_ticket"
+	AmenityLounge          = "lounge"
+	AmenityBabyCare        = "baby_care"
+)
+
+var VenueAmenities = []string{AmenityParking, AmenityWheelchair, AmenityFoodAndBeverage, AmenityRecliners, AmenityMTicket, AmenityLounge, AmenityBabyCare}
+
+// CancellationPolicy is what a venue tells customers about cancelling a booking.
+// Text is shown as is; the other fields are the machine-readable terms.
+type CancellationPolicy struct {
+	Text              string  `json:"text" validate:"max=2000"`
+	AllowCancellation bool    `json:"allow_cancellation"`
+	CutoffMinutes     int     `json:"cutoff_minutes" validate:"min=0"`         // No cancelling this close to the show
+	RefundPercent     float64 `json:"refund_percent" validate:"min=0,max=100"` // Of the ticket price
+	CancellationFee   float64 `json:"cancellation_fee" validate:"min=0"`       // Flat, per order
+}
+
+// DayHours is when a venue is open on one day, as HH:MM. A closing time at or before the
+// opening time runs past midnight, e.g. 09:00-02:00.
+type DayHours struct {
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// HoursException replaces the weekly hours on one date, e.g. a holiday
+type HoursException struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Closed bool   `json:"closed"`
+	Opens  string `json:"opens,omitempty"`
+	Closes string `json:"closes,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// OperatingHours is a venue's weekly timetable keyed by lowercase weekday ("monday").
+// A day missing from Weekly is a closed day.
+type OperatingHours struct {
+	Weekly     map[string]DayHours `json:"weekly"`
+	Exceptions []HoursException    `json:"exceptions,omitempty"`
+}
+
+var (
+	ErrClosed      = errors.New("venue is closed at that time")
+	errInvalidTime = errors.New("times must be HH:MM")
+)
+
+// clockMinutes turns HH:MM into minutes since midnight
+func clockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errInvalidTime
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks every day name, time and exception date
+func (h OperatingHours) Validate() error {
+	for day, hours := range h.Weekly {
+		if _, ok := weekdayByName[day]; !ok {
+			return fmt.Errorf("unknown day %q, use monday to sunday", day)
+		}
+		if _, err := clockMinutes(hours.Opens); err != nil {
+			return fmt.Errorf("%s: %w", day, err)
+		}
+		if _, err := clockMinutes(hours.Closes); err != nil {
+			return fmt.Errorf("%s: %w", day, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, e := range h.Exceptions {
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return fmt.Errorf("exception date %q must be YYYY-MM-DD", e.Date)
+		}
+		if seen[e.Date] {
+			return fmt.Errorf("more than one exception on %s", e.Date)
+		}
+		seen[e.Date] = true
+		if e.Closed {
+			continue
+		}
+		if _, err := clockMinutes(e.Opens); err != nil {
+			return fmt.Errorf("%s: %w", e.Date, err)
+		}
+		if _, err := clockMinutes(e.Closes); err != nil {
+			return fmt.Errorf("%s: %w", e.Date, err)
+		}
+	}
+	return nil
+}
+
+var weekdayByName = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// hoursOn returns the hours that apply on a date, taking exceptions into account
+func (h OperatingHours) hoursOn(date time.Time) (DayHours, bool) {
+	day := date.Format("2006-01-02")
+	for _, e := range h.Exceptions {
+		if e.Date == day {
+			return DayHours{Opens: e.Opens, Closes: e.Closes}, !e.Closed
+		}
+	}
+	hours, ok := h.Weekly[strings.ToLower(date.Weekday().String())]
+	return hours, ok
+}
+
+// span returns a day's hours as minutes from that day's midnight; closing may be past 24:00
+func (d DayHours) span() (int, int) {
+	opens, _ := clockMinutes(d.Opens)
+	closes, _ := clockMinutes(d.Closes)
+	if closes <= opens {
+		closes += 24 * 60
+	}
+	return opens, closes
+}
+
+// IsOpenAt reports whether the venue is open at t, which should be in the venue's timezone.
+// Hours that run past midnight count towards the day they started on.
+func (h OperatingHours) IsOpenAt(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if hours, ok := h.hoursOn(t); ok {
+		opens, closes := hours.span()
+		if minute >= opens && minute < closes {
+			return true
+		}
+	}
+	// Still inside yesterday's late opening
+	if hours, ok := h.hoursOn(t.AddDate(0, 0, -1)); ok {
+		_, closes := hours.span()
+		if minute+24*60 < closes {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsDailyShow reports whether a show at HH:MM every day falls inside the weekly hours of
+// every day the venue opens. Exceptions are ignored; on a holiday the show simply doesn't run.
+func (h OperatingHours) AllowsDailyShow(timing string) bool {
+	minute, err := clockMinutes(timing)
+	if err != nil {
+		return false
+	}
+	if len(h.Weekly) == 0 {
+		return false
+	}
+	// Monday 2024-01-01 is a handy reference week
+	for i := 0; i < 7; i++ {
+		day := time.Date(2024, 1, 1+i, minute/60, minute%60, 0, 0, time.UTC)
+		if _, open := h.Weekly[strings.ToLower(day.Weekday().String())]; !open {
+			continue
+		}
+		if !(OperatingHours{Weekly: h.Weekly}).IsOpenAt(day) {
+			return false
+		}
+	}
+	return true
+}
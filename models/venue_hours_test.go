@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func testHours() OperatingHours {
+	return OperatingHours{
+		Weekly: map[string]DayHours{
+			"monday":   {Opens: "09:00", Closes: "23:00"},
+			"friday":   {Opens: "09:00", Closes: "02:00"}, // Late night
+			"saturday": {Opens: "10:00", Closes: "23:30"},
+		},
+		Exceptions: []HoursException{
+			{Date: "2026-03-09", Closed: true, Note: "Holi"}, // A Monday
+			{Date: "2026-03-14", Opens: "12:00", Closes: "18:00"},
+		},
+	}
+}
+
+func TestOperatingHours_IsOpenAt(t *testing.T) {
+	hours := testHours()
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	cases := map[string]bool{
+		"2026-03-02 08:59": false, // Monday, before opening
+		"2026-03-02 09:00": true,
+		"2026-03-02 23:00": false, // Closing time itself is closed
+		"2026-03-03 12:00": false, // Tuesday isn't listed
+		"2026-03-06 23:45": true,  // Friday late night
+		"2026-03-07 01:30": true,  // ...running into Saturday
+		"2026-03-07 02:00": false,
+		"2026-03-09 12:00": false, // Holiday closure
+		"2026-03-14 11:00": false, // Shortened Saturday
+		"2026-03-14 17:00": true,
+	}
+	for value, expected := range cases {
+		if got := hours.IsOpenAt(at(value)); got != expected {
+			t.Errorf("%s: expected open=%v, got %v", value, expected, got)
+		}
+	}
+}
+
+func TestOperatingHours_AllowsDailyShow(t *testing.T) {
+	hours := testHours()
+	if !hours.AllowsDailyShow("18:00") {
+		t.Error("18:00 is inside the hours of every open day")
+	}
+	if hours.AllowsDailyShow("09:30") {
+		t.Error("09:30 is before Saturday's opening")
+	}
+	if hours.AllowsDailyShow("23:15") {
+		t.Error("23:15 is after Monday's closing")
+	}
+	if (OperatingHours{}).AllowsDailyShow("18:00") {
+		t.Error("a venue that never opens allows no daily show")
+	}
+}
+
+func TestOperatingHours_Validate(t *testing.T) {
+	if err := testHours().Validate(); err != nil {
+		t.Errorf("expected valid hours, got %v", err)
+	}
+	bad := []OperatingHours{
+		{Weekly: map[string]DayHours{"funday": {Opens: "09:00", Closes: "17:00"}}},
+		{Weekly: map[string]DayHours{"monday": {Opens: "9am", Closes: "17:00"}}},
+		{Exceptions: []HoursException{{Date: "25/12/2026", Closed: true}}},
+		{Exceptions: []HoursException{{Date: "2026-12-25", Opens: "10:00"}}},
+		{Exceptions: []HoursException{{Date: "2026-12-25", Closed: true}, {Date: "2026-12-25", Closed: true}}},
+	}
+	for i, hours := range bad {
+		if hours.Validate() == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestVenue_CheckShowTime_UsesCityTimezone(t *testing.T) {
+	hours := OperatingHours{Weekly: map[string]DayHours{"monday": {Opens: "09:00", Closes: "23:00"}}}
+	venue := Venue{OperatingHours: &hours, City: &City{Timezone: "Asia/Kolkata"}}
+
+	// 04:00 UTC is 09:30 in Kolkata
+	morning := time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC)
+	if err := venue.CheckShowTime("", &morning); err != nil {
+		t.Errorf("expected the show to be allowed, got %v", err)
+	}
+	// 03:00 UTC is 08:30 in Kolkata
+	early := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	if err := venue.CheckShowTime("", &early); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := (Venue{}).CheckShowTime("04:00", nil); err != nil {
+		t.Errorf("a venue without hours should accept any show, got %v", err)
+	}
+}
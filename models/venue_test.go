@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShowTime_IsUpcoming(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if !(ShowTime{Timing: "10:00"}).IsUpcoming(now) {
+		t.Error("a daily show without a date should always be upcoming")
+	}
+	if (ShowTime{StartsAt: &past}).IsUpcoming(now) {
+		t.Error("a show that has started should not be upcoming")
+	}
+	if !(ShowTime{StartsAt: &future}).IsUpcoming(now) {
+		t.Error("a show later today should be upcoming")
+	}
+}
+
+func TestShowTime_HasPlayedSince(t *testing.T) {
+	booked := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := booked.Add(-time.Hour), booked.Add(48*time.Hour)
+
+	if !(ShowTime{StartsAt: &past}).HasPlayedSince(booked, booked, time.UTC) {
+		t.Error("a dated show that has started should have played")
+	}
+	if (ShowTime{StartsAt: &future}).HasPlayedSince(booked, booked.Add(24*time.Hour), time.UTC) {
+		t.Error("a dated show next week should not have played")
+	}
+
+	daily := ShowTime{Timing: "18:00"}
+	if daily.HasPlayedSince(booked, booked.Add(5*time.Hour), time.UTC) {
+		t.Error("a daily show booked at noon should not have played by 17:00")
+	}
+	if !daily.HasPlayedSince(booked, booked.Add(6*time.Hour), time.UTC) {
+		t.Error("a daily show booked at noon should have played by 18:00")
+	}
+	// Booked after the morning show, the next one is tomorrow's
+	morning := ShowTime{Timing: "10:00"}
+	if morning.HasPlayedSince(booked, booked.Add(20*time.Hour), time.UTC) {
+		t.Error("a morning show booked at noon should not have played before the next morning")
+	}
+	if !morning.HasPlayedSince(booked, booked.Add(22*time.Hour), time.UTC) {
+		t.Error("a morning show booked at noon should have played the next morning")
+	}
+	// 18:00 in Kolkata is 12:30 UTC
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("no timezone data")
+	}
+	if !daily.HasPlayedSince(booked, booked.Add(31*time.Minute), kolkata) {
+		t.Error("the timing should be read in the venue's timezone")
+	}
+}
+
+func TestScreen_SupportsFormat(t *testing.T) {
+	imax := Screen{Formats: []string{FormatIMAX, Format3D}}
+	for _, format := range []string{"", Format2D, FormatIMAX, Format3D} {
+		if !imax.SupportsFormat(format) {
+			t.Errorf("expected the screen to support %q", format)
+		}
+	}
+	if imax.SupportsFormat(FormatIMAX3D) {
+		t.Error("IMAX and 3D separately don't make an IMAX 3D screen")
+	}
+	if (Screen{}).SupportsFormat(Format4DX) {
+		t.Error("a plain screen should only support 2D")
+	}
+}
+
+func TestCanonicalFormat(t *testing.T) {
+	for input, expected := range map[string]string{"": Format2D, "imax 3d": FormatIMAX3D, " 4dx ": Format4DX, "dolby cinema": FormatDolby} {
+		if got, ok := CanonicalFormat(input); !ok || got != expected {
+			t.Errorf("CanonicalFormat(%q) = %q, %v, expected %q", input, got, ok, expected)
+		}
+	}
+	if _, ok := CanonicalFormat("8K"); ok {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,256 @@
+// Package oidc signs users in with external identity providers over OpenID Connect, using the
+// authorization code flow with PKCE. A Provider finds its endpoints and signing keys through discovery
+// from the issuer URL, so any compliant issuer works, including the stub in oidctest.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scopes asked for on every sign-in
+var Scopes = []string{"openid", "email", "profile"}
+
+// ErrInvalidIDToken is returned when an ID token's signature, issuer, audience, expiry or nonce doesn't check out
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+// Config is what a provider needs to know about the app registered with it
+type Config struct {
+	Name         string // Used in URLs and stored on linked identities, e.g. "google"
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+}
+
+// Metadata is the part of an issuer's discovery document the login flow uses
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the ID token claims a login cares about. Subject identifies the user at the provider
+// and never changes; the email can.
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// flexBool accepts true as well as "true", which some issuers send for email_verified
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	*b = flexBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// Provider is one issuer users can sign in with. Discovery and keys are fetched on first use and cached.
+type Provider struct {
+	Config
+	HTTPClient *http.Client
+
+	mu          sync.Mutex
+	metadata    *Metadata
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// keyRefreshInterval limits how often an unknown key id makes the provider refetch its keys
+const keyRefreshInterval = time.Minute
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{Config: cfg, HTTPClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Discover returns the issuer's discovery document, fetching it the first time
+func (p *Provider) Discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+	var m Metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &m); err != nil {
+		return nil, fmt.Errorf("oidc: discovery for %s: %w", p.Name, err)
+	}
+	// A document for another issuer would let its tokens through
+	if m.Issuer != p.Issuer {
+		return nil, fmt.Errorf("oidc: discovery for %s returned issuer %q", p.Name, m.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery for %s is missing endpoints", p.Name)
+	}
+	p.metadata = &m
+	return p.metadata, nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier, kept until the code is exchanged
+func NewCodeVerifier() string {
+	return randomString(32)
+}
+
+// CodeChallenge is the S256 challenge sent with the authorization request for the verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL is where to send the user to sign in. The provider sends them back to redirectURL
+// with a code and the state.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	m, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(m.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return m.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange swaps the code from the redirect for an ID token and returns its verified claims
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, verifier, nonce string) (*Claims, error) {
+	m, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request to %s: %w", p.Name, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc: token response from %s: %w", p.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("oidc: token request to %s failed: %s %s", p.Name, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("oidc: %s returned no id token", p.Name)
+	}
+	return p.Verify(ctx, body.IDToken, nonce)
+}
+
+// Verify checks an ID token was signed by the issuer for this client and carries the nonce of the login it ends
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	m, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, m.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(m.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// key returns the issuer's public key with the id. An unknown id refetches the keys, since issuers rotate them.
+func (p *Provider) key(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	var set jwkSet
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	p.keys = set.publicKeys()
+	p.keysFetched = time.Now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// lookupKey finds the key with the id. A token without one can use the only key there is.
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,133 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/Snehil208001/BookMyShowApp/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:5173/oidc/callback/stub"
+
+// authorize follows the provider's authorization URL and returns the code and state it redirects back with
+func authorize(t *testing.T, p *Provider, state, nonce, verifier string) (string, string) {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), redirectURL, state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func newStub(t *testing.T) (*oidctest.Issuer, *Provider) {
+	iss := oidctest.NewIssuer("bookmyshow", "s3cret")
+	t.Cleanup(iss.Close)
+	iss.SetUser(oidctest.User{Subject: "user-1", Email: "asha@example.com", EmailVerified: true, Name: "Asha"})
+	return iss, NewProvider(Config{Name: "stub", Issuer: iss.URL, ClientID: "bookmyshow", ClientSecret: "s3cret"})
+}
+
+func TestProvider_Login(t *testing.T) {
+	_, p := newStub(t)
+	verifier := NewCodeVerifier()
+	code, state := authorize(t, p, "state-1", "nonce-1", verifier)
+	if state != "state-1" {
+		t.Errorf("expected state to come back, got %q", state)
+	}
+
+	claims, err := p.Exchange(context.Background(), redirectURL, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "asha@example.com" || !bool(claims.EmailVerified) || claims.Name != "Asha" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err := p.Exchange(context.Background(), redirectURL, code, verifier, "nonce-1"); err == nil {
+		t.Error("expected a code to work only once")
+	}
+}
+
+func TestProvider_ExchangeChecksVerifierAndNonce(t *testing.T) {
+	_, p := newStub(t)
+
+	code, _ := authorize(t, p, "state", "nonce", NewCodeVerifier())
+	if _, err := p.Exchange(context.Background(), redirectURL, code, NewCodeVerifier(), "nonce"); err == nil {
+		t.Error("expected a different code verifier to be refused")
+	}
+
+	verifier := NewCodeVerifier()
+	code, _ = authorize(t, p, "state", "nonce", verifier)
+	if _, err := p.Exchange(context.Background(), redirectURL, code, verifier, "other-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("expected ErrInvalidIDToken for a nonce from another login, got %v", err)
+	}
+}
+
+func TestProvider_Verify(t *testing.T) {
+	iss, p := newStub(t)
+	now := time.Now()
+	claims := func(override jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{"iss": iss.URL, "sub": "user-1", "aud": "bookmyshow", "exp": now.Add(time.Hour).Unix(), "nonce": "n"}
+		for k, v := range override {
+			c[k] = v
+		}
+		return c
+	}
+
+	if _, err := p.Verify(context.Background(), iss.SignIDToken(claims(nil)), "n"); err != nil {
+		t.Fatalf("expected a valid token to verify, got %v", err)
+	}
+	bad := map[string]jwt.MapClaims{
+		"other audience": {"aud": "someone-else"},
+		"other issuer":   {"iss": "https://evil.example.com"},
+		"expired":        {"exp": now.Add(-time.Hour).Unix()},
+		"no subject":     {"sub": ""},
+	}
+	for name, override := range bad {
+		if _, err := p.Verify(context.Background(), iss.SignIDToken(claims(override)), "n"); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, got %v", name, err)
+		}
+	}
+
+	// An HMAC token signed with the client secret must not pass for one signed by the issuer
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("s3cret"))
+	if _, err := p.Verify(context.Background(), forged, "n"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("expected an HS256 token to be refused, got %v", err)
+	}
+
+	// After the issuer rotates its key the new one is fetched once the refresh interval has passed
+	iss.RotateKey("key-2")
+	p.keysFetched = time.Time{}
+	if _, err := p.Verify(context.Background(), iss.SignIDToken(claims(nil)), "n"); err != nil {
+		t.Errorf("expected a token signed with a rotated key to verify, got %v", err)
+	}
+}
+
+func TestProvider_DiscoverChecksIssuer(t *testing.T) {
+	iss, _ := newStub(t)
+	p := NewProvider(Config{Name: "stub", Issuer: iss.URL + "/", ClientID: "bookmyshow"})
+	if _, err := p.Discover(context.Background()); err == nil {
+		t.Error("expected discovery for a different issuer URL to be refused")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636 appendix B
+	if got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %q", got)
+	}
+}
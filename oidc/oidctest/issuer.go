@@ -0,0 +1,192 @@
+// Package oidctest runs a stub OpenID Connect issuer on a local port, so the sign-in flow can be tested
+// without a real provider. It approves every authorization request as User without showing a page.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User is who the issuer signs people in as
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Issuer is a running stub issuer. Its URL is the issuer to configure a provider with.
+type Issuer struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu    sync.Mutex
+	user  User
+	key   *rsa.PrivateKey
+	kid   string
+	codes map[string]grant
+}
+
+// grant is an authorization code waiting to be exchanged
+type grant struct {
+	user        User
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// NewIssuer starts a stub issuer for the client. Close it when done.
+func NewIssuer(clientID, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	iss := &Issuer{ClientID: clientID, ClientSecret: clientSecret, key: key, kid: "key-1", codes: map[string]grant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("/authorize", iss.authorize)
+	mux.HandleFunc("/token", iss.token)
+	mux.HandleFunc("/jwks", iss.jwks)
+	iss.Server = httptest.NewServer(mux)
+	return iss
+}
+
+// SetUser changes who the next authorization signs in as
+func (iss *Issuer) SetUser(u User) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.user = u
+}
+
+// RotateKey switches to a new signing key, as real issuers do from time to time
+func (iss *Issuer) RotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.key, iss.kid = key, kid
+}
+
+// SignIDToken signs claims with the issuer's key, for tests that need a token the flow wouldn't issue
+func (iss *Issuer) SignIDToken(claims jwt.MapClaims) string {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = iss.kid
+	signed, err := token.SignedString(iss.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (iss *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                           iss.URL,
+		"authorization_endpoint":           iss.URL + "/authorize",
+		"token_endpoint":                   iss.URL + "/token",
+		"jwks_uri":                         iss.URL + "/jwks",
+		"response_types_supported":         []string{"code"},
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+// authorize approves the request straight away and redirects back with a code
+func (iss *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("client_id") != iss.ClientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := randomString()
+	iss.mu.Lock()
+	iss.codes[code] = grant{user: iss.user, redirectURI: q.Get("redirect_uri"), challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	iss.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token once, checking the client, redirect and PKCE verifier
+func (iss *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	secret, _ = url.QueryUnescape(secret)
+	if clientID != iss.ClientID || secret != iss.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	iss.mu.Lock()
+	g, ok := iss.codes[r.PostFormValue("code")]
+	delete(iss.codes, r.PostFormValue("code"))
+	iss.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != g.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	idToken := iss.SignIDToken(jwt.MapClaims{
+		"iss":            iss.URL,
+		"sub":            g.user.Subject,
+		"aud":            iss.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"name":           g.user.Name,
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": randomString(), "token_type": "Bearer", "id_token": idToken, "expires_in": 3600})
+}
+
+func (iss *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	iss.mu.Lock()
+	pub, kid := iss.key.PublicKey, iss.kid
+	iss.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
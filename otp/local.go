@@ -0,0 +1,68 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// LocalProvider keeps codes in memory and writes them to the log instead of sending an SMS.
+// Each phone has one code per purpose. Codes are lost on restart, so it's only meant for development and tests.
+type LocalProvider struct {
+	TTL   time.Duration
+	mu    sync.Mutex
+	codes map[localKey]localCode
+}
+
+type localKey struct {
+	phone, purpose string
+}
+
+type localCode struct {
+	code      string
+	expiresAt time.Time
+}
+
+func NewLocalProvider(ttl time.Duration) *LocalProvider {
+	return &LocalProvider{TTL: ttl, codes: map[localKey]localCode{}}
+}
+
+func (p *LocalProvider) Name() string { return ProviderLocal }
+
+func (p *LocalProvider) Send(ctx context.Context, phone, purpose string) error {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	p.mu.Lock()
+	p.codes[localKey{phone, purpose}] = localCode{code: code, expiresAt: time.Now().Add(p.TTL)}
+	p.mu.Unlock()
+	log.Printf("[otp] %s code for %s is %s\n", purpose, phone, code)
+	return nil
+}
+
+// Check accepts the latest code sent to the phone for the purpose once
+func (p *LocalProvider) Check(ctx context.Context, phone, purpose, code string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := localKey{phone, purpose}
+	sent, ok := p.codes[key]
+	if !ok || time.Now().After(sent.expiresAt) || subtle.ConstantTimeCompare([]byte(sent.code), []byte(code)) != 1 {
+		return ErrInvalidCode
+	}
+	delete(p.codes, key)
+	return nil
+}
+
+// LastCode returns the code waiting for the phone and purpose, for tests
+func (p *LocalProvider) LastCode(phone, purpose string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.codes[localKey{phone, purpose}].code
+}
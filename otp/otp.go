@@ -0,0 +1,101 @@
+// Package otp sends and checks one-time codes by SMS for phone login and phone verification.
+// Providers plug in through OTPProvider: TwilioProvider uses Twilio Verify, LocalProvider keeps codes
+// in memory and logs them, for development and tests.
+package otp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Providers that can be chosen with OTP_PROVIDER
+const (
+	ProviderLocal  = "local"
+	ProviderTwilio = "twilio"
+)
+
+var (
+	ErrInvalidPhone = errors.New("invalid phone number, use international format such as +919876543210")
+	// ErrInvalidCode is returned by Check for a wrong or expired code
+	ErrInvalidCode = errors.New("invalid or expired code")
+)
+
+// OTPProvider delivers a code to a phone and later checks the code the user typed. The purpose (login or
+// phone verification) keeps codes sent to one phone for different reasons apart, where the provider can.
+// Phone numbers are always in E.164 form, see NormalizePhone.
+type OTPProvider interface {
+	Name() string
+	Send(ctx context.Context, phone, purpose string) error
+	// Check returns ErrInvalidCode when the code is wrong or has expired
+	Check(ctx context.Context, phone, purpose, code string) error
+}
+
+// NormalizePhone turns a phone number into E.164, e.g. "+919876543210". Spaces, dashes, dots and
+// brackets are ignored and a leading 00 counts as +. Numbers without a country code get
+// defaultCountryCode (digits only, e.g. "91") after dropping a leading trunk 0; with no default they are rejected.
+func NormalizePhone(raw, defaultCountryCode string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	number := b.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(number, "00"):
+		number = "+" + number[2:]
+	case defaultCountryCode != "":
+		number = "+" + strings.TrimPrefix(defaultCountryCode, "+") + strings.TrimPrefix(number, "0")
+	default:
+		return "", ErrInvalidPhone
+	}
+	// E.164 allows at most 15 digits and country codes never start with 0
+	digits := number[1:]
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return number, nil
+}
+
+// Policy limits how codes are sent and tried
+type Policy struct {
+	TTL             time.Duration // How long a code can be used
+	ResendCooldown  time.Duration // Between two codes to the same phone
+	MaxSendsPerHour int           // Codes to the same phone in any hour
+	MaxAttempts     int           // Wrong guesses before the code has to be sent again
+}
+
+var DefaultPolicy = Policy{
+	TTL:             10 * time.Minute,
+	ResendCooldown:  time.Minute,
+	MaxSendsPerHour: 5,
+	MaxAttempts:     5,
+}
+
+// SendWait is how long until another code may be sent, given when codes went to the phone
+// in the last hour, oldest first. Zero means now.
+func (p Policy) SendWait(sentLastHour []time.Time, now time.Time) time.Duration {
+	var wait time.Duration
+	if n := len(sentLastHour); n > 0 {
+		wait = sentLastHour[n-1].Add(p.ResendCooldown).Sub(now)
+	}
+	if n := len(sentLastHour); p.MaxSendsPerHour > 0 && n >= p.MaxSendsPerHour {
+		// Wait for the send that put us at the limit to fall out of the hour
+		if w := sentLastHour[n-p.MaxSendsPerHour].Add(time.Hour).Sub(now); w > wait {
+			wait = w
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
@@ -0,0 +1,99 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := []struct {
+		raw, defaultCode, want string
+	}{
+		{"+91 98765 43210", "", "+919876543210"},
+		{"0091-98765-43210", "", "+919876543210"},
+		{"98765 43210", "91", "+919876543210"},
+		{"098765 43210", "+91", "+919876543210"},
+		{"(020) 7946 0958", "44", "+442079460958"},
+		{"+1 (415) 555-2671", "91", "+14155552671"},
+	}
+	for _, tc := range cases {
+		got, err := NormalizePhone(tc.raw, tc.defaultCode)
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizePhone(%q, %q) = %q, %v; want %q", tc.raw, tc.defaultCode, got, err, tc.want)
+		}
+	}
+	for _, raw := range []string{"", "9876543210", "+91 98765x43210", "+0123456789", "+1234", "+1234567890123456", "91+9876543210"} {
+		if got, err := NormalizePhone(raw, ""); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q) = %q, %v; want ErrInvalidPhone", raw, got, err)
+		}
+	}
+}
+
+func TestPolicy_SendWait(t *testing.T) {
+	p := Policy{ResendCooldown: time.Minute, MaxSendsPerHour: 3}
+	now := time.Now()
+
+	if wait := p.SendWait(nil, now); wait != 0 {
+		t.Errorf("expected no wait for a first code, got %v", wait)
+	}
+	if wait := p.SendWait([]time.Time{now.Add(-20 * time.Second)}, now); wait != 40*time.Second {
+		t.Errorf("expected the rest of the cooldown, got %v", wait)
+	}
+	if wait := p.SendWait([]time.Time{now.Add(-5 * time.Minute)}, now); wait != 0 {
+		t.Errorf("expected no wait after the cooldown, got %v", wait)
+	}
+	sent := []time.Time{now.Add(-50 * time.Minute), now.Add(-30 * time.Minute), now.Add(-10 * time.Minute)}
+	if wait := p.SendWait(sent, now); wait != 10*time.Minute {
+		t.Errorf("expected to wait for the oldest code to leave the hour, got %v", wait)
+	}
+}
+
+func TestLocalProvider(t *testing.T) {
+	ctx := context.Background()
+	p := NewLocalProvider(time.Minute)
+	phone := "+919876543210"
+	if err := p.Send(ctx, phone, "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code := p.LastCode(phone, "login")
+	if len(code) != 6 {
+		t.Fatalf("expected a 6 digit code, got %q", code)
+	}
+	if err := p.Check(ctx, phone, "login", "not-it"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected ErrInvalidCode for a wrong code, got %v", err)
+	}
+	if err := p.Check(ctx, phone, "login", code); err != nil {
+		t.Errorf("expected the code to be accepted, got %v", err)
+	}
+	if err := p.Check(ctx, phone, "login", code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected a code to work once, got %v", err)
+	}
+
+	expired := NewLocalProvider(-time.Second)
+	expired.Send(ctx, phone, "login")
+	if err := expired.Check(ctx, phone, "login", expired.LastCode(phone, "login")); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected an expired code to be rejected, got %v", err)
+	}
+}
+
+func TestLocalProvider_KeepsPurposesApart(t *testing.T) {
+	ctx := context.Background()
+	p := NewLocalProvider(time.Minute)
+	phone := "+919876543210"
+	p.Send(ctx, phone, "login")
+	login := p.LastCode(phone, "login")
+	p.Send(ctx, phone, "verify_phone")
+	verify := p.LastCode(phone, "verify_phone")
+
+	if err := p.Check(ctx, phone, "verify_phone", login); login != verify && !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected a login code to be refused for verification, got %v", err)
+	}
+	if err := p.Check(ctx, phone, "login", login); err != nil {
+		t.Errorf("expected the login code to survive a verification code to the same phone, got %v", err)
+	}
+	if err := p.Check(ctx, phone, "verify_phone", verify); err != nil {
+		t.Errorf("expected the verification code to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
+	openapi "github.com/twilio/twilio-go/rest/verify/v2"
+)
+
+// TwilioProvider sends and checks codes with a Twilio Verify service, which generates the codes itself.
+// Verify keeps one pending code per phone, so the purpose isn't passed on.
+type TwilioProvider struct {
+	client    *twilio.RestClient
+	serviceID string
+}
+
+func NewTwilioProvider(accountSID, authToken, serviceID string) *TwilioProvider {
+	client := twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: accountSID,
+		Password: authToken,
+	})
+	return &TwilioProvider{client: client, serviceID: serviceID}
+}
+
+func (p *TwilioProvider) Name() string { return ProviderTwilio }
+
+func (p *TwilioProvider) Send(ctx context.Context, phone, purpose string) error {
+	params := &openapi.CreateVerificationParams{}
+	params.SetTo(phone)
+	params.SetChannel("sms")
+	if _, err := p.client.VerifyV2.CreateVerification(p.serviceID, params); err != nil {
+		return fmt.Errorf("twilio: send code: %w", err)
+	}
+	return nil
+}
+
+func (p *TwilioProvider) Check(ctx context.Context, phone, purpose, code string) error {
+	params := &openapi.CreateVerificationCheckParams{}
+	params.SetTo(phone)
+	params.SetCode(code)
+	resp, err := p.client.VerifyV2.CreateVerificationCheck(p.serviceID, params)
+	if err != nil {
+		// Twilio answers 404 once the verification has expired or been approved
+		var restErr *client.TwilioRestError
+		if errors.As(err, &restErr) && restErr.Status == http.StatusNotFound {
+			return ErrInvalidCode
+		}
+		return fmt.Errorf("twilio: check code: %w", err)
+	}
+	if resp.Status == nil || *resp.Status != "approved" {
+		return ErrInvalidCode
+	}
+	return nil
+}
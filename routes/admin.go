@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/Snehil208001/BookMyShowApp/controllers"
+	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(c *gin.Engine) {
+	Admin := c.Group("/admin", middleware.RequireAuth)
+	{
+		Admin.POST("/catalog/:kind/import", middleware.RequirePermission(models.PermManageCatalog), controllers.ImportCatalog)
+		Admin.GET("/catalog/:kind/export", middleware.RequirePermission(models.PermManageCatalog), controllers.ExportCatalog)
+		Admin.POST("/metadata/resync", middleware.RequirePermission(models.PermManageCatalog), controllers.ResyncMetadata)
+		Admin.GET("/users", middleware.RequirePermission(models.PermManageUsers), controllers.SearchUsers)
+		Admin.GET("/users/:id", middleware.RequirePermission(models.PermManageUsers), controllers.GetUserForAdmin)
+		Admin.GET("/users/:id/orders", middleware.RequirePermission(models.PermManageUsers), controllers.GetUserOrdersForAdmin)
+		Admin.POST("/users/:id/disable", middleware.RequirePermission(models.PermManageUsers), controllers.DisableUser)
+		Admin.POST("/users/:id/enable", middleware.RequirePermission(models.PermManageUsers), controllers.EnableUser)
+		Admin.POST("/users/:id/password-reset", middleware.RequirePermission(models.PermManageUsers), controllers.ForcePasswordReset)
+		Admin.POST("/users/:id/revoke-sessions", middleware.RequirePermission(models.PermManageUsers), controllers.RevokeUserSessions)
+		Admin.GET("/audit", middleware.RequirePermission(models.PermViewAudit), controllers.GetAuditLog)
+		Admin.GET("/roles", middleware.RequirePermission(models.PermManageRoles), controllers.GetRoleDefinitions)
+		Admin.GET("/users/:id/roles", middleware.RequirePermission(models.PermManageRoles), controllers.GetUserRoles)
+		Admin.POST("/users/:id/roles", middleware.RequirePermission(models.PermManageRoles), controllers.GrantRole)
+		Admin.DELETE("/users/:id/roles/:roleId", middleware.RequirePermission(models.PermManageRoles), controllers.RevokeRole)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/controllers"
+	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+func CityRoutes(c *gin.Engine) {
+	City := c.Group("/cities")
+	{
+		City.GET("/", controllers.GetCities)
+		City.POST("/", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.CreateCity)
+		City.PATCH("/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UpdateCity)
+	}
+	Region := c.Group("/regions")
+	{
+		Region.GET("/", controllers.GetRegions)
+		Region.POST("/", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.CreateRegion)
+		Region.PATCH("/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UpdateRegion)
+	}
+}
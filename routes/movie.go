@@ -4,16 +4,29 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/Snehil208001/BookMyShowApp/controllers"
 	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
 )
 
 func MovieRoutes(c *gin.Engine) {
 	Movie := c.Group("/movies")
 	{
-		Movie.GET("/", controllers.GetAllMovies)
-		Movie.POST("/", middleware.RequireAuth, controllers.CreateMovie)
+		Movie.GET("/", middleware.OptionalAuth, controllers.GetAllMovies)
+		Movie.POST("/", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.CreateMovie)
 		Movie.GET("/:id", controllers.GetMovieByID)
-		Movie.GET("/venues/:id", controllers.GetVenuesByMovieID)
-		Movie.PATCH("/:id/poster", middleware.RequireAuth, controllers.UpdateMoviePoster)
-		Movie.POST("/upload/poster/:id", middleware.RequireAuth, controllers.UploadMoviePoster)
+		Movie.GET("/venues/:id", middleware.OptionalAuth, controllers.GetVenuesByMovieID)
+		Movie.PATCH("/:id/poster", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UpdateMoviePoster)
+		Movie.POST("/upload/poster/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UploadMoviePoster)
+		Movie.GET("/:id/media", controllers.GetMovieMedia)
+		Movie.POST("/:id/media", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.AddMovieMedia)
+		Movie.PATCH("/:id/media/:mediaId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UpdateMovieMedia)
+		Movie.DELETE("/:id/media/:mediaId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.DeleteMovieMedia)
+		Movie.GET("/:id/media/:mediaId/signed-url", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.GetMovieMediaSignedURL)
+		Movie.POST("/import", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.ImportMovieMetadata)
+		Movie.POST("/:id/metadata/refresh", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.RefreshMovieMetadata)
+		Movie.PATCH("/:id/lifecycle", middleware.RequireAuth, middleware.RequirePermission(models.PermManageCatalog), controllers.UpdateMovieLifecycle)
+		Movie.POST("/:id/interest", middleware.RequireAuth, controllers.RegisterMovieInterest)
+		Movie.DELETE("/:id/interest", middleware.RequireAuth, controllers.RemoveMovieInterest)
+		Movie.GET("/:id/reviews", controllers.GetMovieReviews)
+		Movie.POST("/:id/reviews", middleware.RequireAuth, controllers.CreateReview)
 	}
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/Snehil208001/BookMyShowApp/controllers"
+	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
+)
+
+func OperatorRoutes(c *gin.Engine) {
+	Operator := c.Group("/operators")
+	{
+		Operator.GET("/", controllers.GetOperators)
+		Operator.GET("/:id", middleware.OptionalAuth, controllers.GetOperatorByID)
+		Operator.POST("/", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperators), controllers.CreateOperator)
+		Operator.PATCH("/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperator), controllers.UpdateOperator)
+		Operator.GET("/:id/report", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperator), controllers.GetOperatorReport)
+		Operator.GET("/:id/admins", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperator), controllers.GetOperatorAdmins)
+		Operator.PUT("/:id/admins/:userId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperators), controllers.AddOperatorAdmin)
+		Operator.DELETE("/:id/admins/:userId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageOperators), controllers.RemoveOperatorAdmin)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Snehil208001/BookMyShowApp/controllers"
+	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ReviewRoutes(c *gin.Engine) {
+	Review := c.Group("/reviews")
+	{
+		Review.GET("/moderation", middleware.RequireAuth, middleware.RequirePermission(models.PermModerateReviews), controllers.GetReviewsForModeration)
+		Review.PATCH("/:id", middleware.RequireAuth, controllers.UpdateReview)
+		Review.DELETE("/:id", middleware.RequireAuth, controllers.DeleteReview)
+		Review.POST("/:id/helpful", middleware.RequireAuth, controllers.VoteReviewHelpful)
+		Review.DELETE("/:id/helpful", middleware.RequireAuth, controllers.RemoveReviewVote)
+		Review.PATCH("/:id/moderate", middleware.RequireAuth, middleware.RequirePermission(models.PermModerateReviews), controllers.ModerateReview)
+	}
+}
@@ -9,7 +9,7 @@ import (
 func SeatRoutes(c *gin.Engine) {
 	Seat := c.Group("/seats")
 	{
-		Seat.GET("/showtime/:id", controllers.GetSeatLayout)
+		Seat.GET("/showtime/:id", middleware.OptionalAuth, controllers.GetSeatLayout)
 		Seat.POST("/showtime/reserve", middleware.RequireAuth, controllers.ReserveSeats)
 		Seat.POST("/showtime/book", middleware.RequireAuth, controllers.BookSeats)
 	}
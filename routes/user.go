@@ -11,7 +11,36 @@ func UserRoutes(c *gin.Engine) {
 	{
 		User.POST("/login", controllers.Login)
 		User.POST("/signup", controllers.SignUp)
+		User.POST("/login/otp/send", controllers.SendLoginOTP)
+		User.POST("/login/otp", controllers.LoginWithOTP)
+		User.POST("/login/2fa", controllers.LoginTwoFactor)
+		User.GET("/oidc/providers", controllers.GetOIDCProviders)
+		User.POST("/oidc/:provider/login", controllers.StartOIDCLogin)
+		User.POST("/oidc/:provider/callback", middleware.OptionalAuth, controllers.OIDCCallback)
+		User.POST("/token/refresh", controllers.RefreshTokens)
+		User.POST("/password/forgot", controllers.ForgotPassword)
+		User.POST("/password/reset", controllers.ResetPassword)
+		User.POST("/password/change", middleware.RequireAuth, controllers.ChangePassword)
+		User.POST("/email/verify", controllers.VerifyEmail)
+		User.POST("/email/verify/resend", middleware.RequireAuth, controllers.ResendVerification)
 		User.GET("/me", middleware.RequireAuth, controllers.GetMe)
+		User.PATCH("/me", middleware.RequireAuth, controllers.UpdateMe)
+		User.DELETE("/me", middleware.RequireAuth, controllers.DeleteMe)
+		User.POST("/me/deletion/cancel", middleware.RequireAuth, controllers.CancelAccountDeletion)
+		User.GET("/me/export", middleware.RequireAuth, controllers.ExportMyData)
 		User.POST("/logout", middleware.RequireAuth, controllers.Logout)
+		User.GET("/recommendations", middleware.RequireAuth, controllers.GetRecommendations)
+		User.PUT("/me/city", middleware.RequireAuth, controllers.SetPreferredCity)
+		User.POST("/phone/otp", middleware.RequireAuth, controllers.SendPhoneVerification)
+		User.POST("/phone/verify", middleware.RequireAuth, controllers.VerifyPhone)
+		User.POST("/2fa/setup", middleware.RequireAuth, controllers.SetupTwoFactor)
+		User.POST("/2fa/enable", middleware.RequireAuth, controllers.EnableTwoFactor)
+		User.POST("/2fa/disable", middleware.RequireAuth, controllers.DisableTwoFactor)
+		User.POST("/2fa/recovery-codes", middleware.RequireAuth, controllers.RegenerateRecoveryCodes)
+		User.POST("/oidc/:provider/link", middleware.RequireAuth, controllers.StartOIDCLink)
+		User.GET("/identities", middleware.RequireAuth, controllers.GetIdentities)
+		User.DELETE("/identities/:id", middleware.RequireAuth, controllers.UnlinkIdentity)
+		User.GET("/sessions", middleware.RequireAuth, controllers.GetSessions)
+		User.DELETE("/sessions/:id", middleware.RequireAuth, controllers.RevokeSession)
 	}
 }
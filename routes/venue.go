@@ -4,15 +4,27 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/Snehil208001/BookMyShowApp/controllers"
 	"github.com/Snehil208001/BookMyShowApp/middleware"
+	"github.com/Snehil208001/BookMyShowApp/models"
 )
 
 func VenueRoutes(c *gin.Engine) {
 	Venue := c.Group("/venues")
 	{
-		Venue.GET("/", controllers.GetAllVenues)
-		Venue.POST("/", middleware.RequireAuth, controllers.CreateVenue)
-		Venue.POST("/:id/movies/add", middleware.RequireAuth, controllers.AddMoviesInVenue)
+		Venue.GET("/", middleware.OptionalAuth, controllers.GetAllVenues)
+		Venue.GET("/nearby", middleware.OptionalAuth, controllers.GetNearbyVenues)
+		Venue.POST("/", middleware.RequireAuth, middleware.RequirePermission(models.PermManageVenues), controllers.CreateVenue)
+		Venue.POST("/:id/movies/add", middleware.RequireAuth, middleware.RequirePermission(models.PermManageShows), controllers.AddMoviesInVenue)
 		Venue.GET("/:id", controllers.GetVenueByID)
-		Venue.POST("/:id/timings/add", middleware.RequireAuth, controllers.AddShowTimings)
+		Venue.PATCH("/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageVenues), controllers.UpdateVenue)
+		Venue.DELETE("/:id", middleware.RequireAuth, middleware.RequirePermission(models.PermManageVenues), controllers.DeleteVenue)
+		Venue.DELETE("/:id/movies/:movieId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageShows), controllers.RemoveMovieFromVenue)
+		Venue.GET("/:id/screens", controllers.GetVenueScreens)
+		Venue.POST("/:id/screens", middleware.RequireAuth, middleware.RequirePermission(models.PermManageScreens), controllers.CreateScreen)
+		Venue.PATCH("/:id/screens/:screenId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageScreens), controllers.UpdateScreen)
+		Venue.DELETE("/:id/screens/:screenId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageScreens), controllers.DeleteScreen)
+		Venue.GET("/:id/orders", middleware.RequireAuth, middleware.RequirePermission(models.PermViewSales), controllers.GetVenueOrders)
+		Venue.POST("/:id/timings/add", middleware.RequireAuth, middleware.RequirePermission(models.PermManageShows), controllers.AddShowTimings)
+		Venue.PATCH("/:id/timings/:showtimeId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageShows), controllers.UpdateShowTime)
+		Venue.DELETE("/:id/timings/:showtimeId", middleware.RequireAuth, middleware.RequirePermission(models.PermManageShows), controllers.CancelShowTime)
 	}
 }
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore keeps files in a directory on disk. The API serves the directory at the path of BaseURL.
+type LocalStore struct {
+	Dir     string
+	BaseURL string // e.g. http://localhost:8080/uploads
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{Dir: dir, BaseURL: baseURL}, nil
+}
+
+func (s *LocalStore) Name() string {
+	return BackendLocal
+}
+
+// ServePath is the URL path the API should serve Dir under
+func (s *LocalStore) ServePath() string {
+	u, err := url.Parse(s.BaseURL)
+	if err != nil || u.Path == "" || u.Path == "/" {
+		return "/uploads"
+	}
+	return u.Path
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	target := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	// Write to a temporary file and rename it, so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) URL(key string) string {
+	return joinURL(s.BaseURL, key)
+}
+
+// SignedURL isn't available, local files are always public
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
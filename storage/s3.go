@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Config configures an S3Store. Endpoint is only set for S3-compatible services.
+type S3Config struct {
+	Bucket    string
+	Region    string
+	AccessKey string // Empty uses the default AWS credential chain
+	SecretKey string
+	Endpoint  string // e.g. http://localhost:9000 for MinIO
+	PathStyle bool   // Address the bucket as endpoint/bucket rather than bucket.endpoint
+	PublicURL string // Optional CDN or custom domain that serves the bucket
+}
+
+// S3Store keeps files in an S3 bucket
+type S3Store struct {
+	config   S3Config
+	client   *s3.S3
+	uploader *s3manager.Uploader
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("storage: an S3 bucket name is required")
+	}
+	if config.Region == "" {
+		// S3-compatible services mostly ignore the region but the signer needs one
+		config.Region = "us-east-1"
+	}
+	awsConfig := aws.Config{
+		Region:           aws.String(config.Region),
+		S3ForcePathStyle: aws.Bool(config.PathStyle),
+	}
+	if config.AccessKey != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+	}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+	}
+	awsSession, err := session.NewSession(&awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{
+		config:   config,
+		client:   s3.New(awsSession),
+		uploader: s3manager.NewUploader(awsSession),
+	}, nil
+}
+
+func (s *S3Store) Name() string {
+	if s.config.Endpoint != "" {
+		return BackendS3Compatible
+	}
+	return BackendS3
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := s.uploader.UploadWithContext(ctx, input); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+// Delete removes the object. S3 reports success for keys that don't exist.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3Store) URL(key string) string {
+	switch {
+	case s.config.PublicURL != "":
+		return joinURL(s.config.PublicURL, key)
+	case s.config.Endpoint == "":
+		return joinURL("https://"+s.config.Bucket+".s3.amazonaws.com", key)
+	case s.config.PathStyle:
+		return joinURL(joinURL(s.config.Endpoint, s.config.Bucket), key)
+	}
+	endpoint, err := url.Parse(s.config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return joinURL(joinURL(s.config.Endpoint, s.config.Bucket), key)
+	}
+	endpoint.Host = s.config.Bucket + "." + endpoint.Host
+	return joinURL(endpoint.String(), key)
+}
+
+// SignedURL presigns a GET for the object. No request is made, so it works offline.
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	req.SetContext(ctx)
+	return req.Presign(expiry)
+}
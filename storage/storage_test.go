@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	valid := []string{"a.jpg", "movies/1/poster/123/card.jpg", "with space/file.webp"}
+	invalid := []string{"", "/abs.jpg", "../escape.jpg", "movies/../../etc/passwd", "movies//double.jpg", "movies/./x.jpg", "dir/", `win\path.jpg`}
+	for _, key := range valid {
+		if !ValidKey(key) {
+			t.Errorf("expected %q to be valid", key)
+		}
+	}
+	for _, key := range invalid {
+		if ValidKey(key) {
+			t.Errorf("expected %q to be invalid", key)
+		}
+	}
+}
+
+func TestLocalStore_PutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "http://localhost:8080/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	url, err := store.Put(ctx, "movies/1/card.jpg", strings.NewReader("first"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://localhost:8080/uploads/movies/1/card.jpg" {
+		t.Errorf("unexpected URL %s", url)
+	}
+	// Put replaces what was there
+	if _, err := store.Put(ctx, "movies/1/card.jpg", strings.NewReader("second"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "movies", "1", "card.jpg"))
+	if err != nil || string(data) != "second" {
+		t.Errorf("expected replaced contents, got %q (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "movies", "1"))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, found %d entries", len(entries))
+	}
+
+	if err := store.Delete(ctx, "movies/1/card.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "movies", "1", "card.jpg")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the file to be gone, got %v", err)
+	}
+	if err := store.Delete(ctx, "movies/1/card.jpg"); err != nil {
+		t.Errorf("deleting a missing key should succeed, got %v", err)
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(context.Background(), "../outside.jpg", strings.NewReader("x"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+	if err := store.Delete(context.Background(), "../outside.jpg"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestLocalStore_NoSignedURLs(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir(), "/uploads")
+	if _, err := store.SignedURL(context.Background(), "a.jpg", time.Minute); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+	if got := store.ServePath(); got != "/uploads" {
+		t.Errorf("expected /uploads, got %s", got)
+	}
+	store.BaseURL = "https://cdn.example.com/static/files"
+	if got := store.ServePath(); got != "/static/files" {
+		t.Errorf("expected /static/files, got %s", got)
+	}
+}
+
+func TestS3Store_URL(t *testing.T) {
+	cases := []struct {
+		name   string
+		config S3Config
+		want   string
+	}{
+		{"aws", S3Config{Bucket: "posters", Region: "ap-south-1"}, "https://posters.s3.amazonaws.com/movies/1/card%20a.jpg"},
+		{"path style", S3Config{Bucket: "posters", Endpoint: "http://localhost:9000", PathStyle: true}, "http://localhost:9000/posters/movies/1/card%20a.jpg"},
+		{"virtual host", S3Config{Bucket: "posters", Endpoint: "https://storage.example.com"}, "https://posters.storage.example.com/movies/1/card%20a.jpg"},
+		{"public url", S3Config{Bucket: "posters", Endpoint: "http://localhost:9000", PathStyle: true, PublicURL: "https://cdn.example.com"}, "https://cdn.example.com/movies/1/card%20a.jpg"},
+	}
+	for _, tc := range cases {
+		store, err := NewS3Store(tc.config)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := store.URL("movies/1/card a.jpg"); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestS3Store_SignedURL(t *testing.T) {
+	store, err := NewS3Store(S3Config{
+		Bucket:    "posters",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+		Endpoint:  "http://localhost:9000",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Name() != BackendS3Compatible {
+		t.Errorf("expected %s, got %s", BackendS3Compatible, store.Name())
+	}
+	signed, err := store.SignedURL(context.Background(), "movies/1/card.jpg", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "localhost:9000" || u.Path != "/posters/movies/1/card.jpg" {
+		t.Errorf("expected a path-style URL on the endpoint, got %s", signed)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Signature") == "" || q.Get("X-Amz-Expires") != "600" {
+		t.Errorf("expected a presigned URL valid for 600s, got %s", signed)
+	}
+}
+
+func TestNewS3Store_RequiresBucket(t *testing.T) {
+	if _, err := NewS3Store(S3Config{}); err == nil {
+		t.Error("expected an error without a bucket")
+	}
+}
@@ -0,0 +1,61 @@
+// Package storage keeps uploaded files in a BlobStore. Files can live on the local disk, served by the API itself,
+// in AWS S3, or in any S3-compatible service such as MinIO or Cloudflare R2.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Backends that can be chosen with STORAGE_BACKEND
+const (
+	BackendLocal        = "local"
+	BackendS3           = "s3"
+	BackendS3Compatible = "s3-compatible"
+)
+
+var (
+	// ErrNotSupported is returned by operations a backend can't do, such as signing URLs for local files
+	ErrNotSupported = errors.New("not supported by this storage backend")
+	ErrInvalidKey   = errors.New("invalid storage key")
+)
+
+// BlobStore stores files under slash separated keys such as "movies/1/poster/123/card.jpg"
+type BlobStore interface {
+	Name() string
+	// Put stores body under key, replacing anything already there, and returns its public URL
+	Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error)
+	// Delete removes the file under key. A key that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is the public URL of key
+	URL(key string) string
+	// SignedURL is a time limited URL for key, which works even when the files aren't public
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// ValidKey reports whether key is a clean relative path that can't escape the store
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// joinURL appends an escaped key to a base URL
+func joinURL(base, key string) string {
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.Join(segments, "/")
+}